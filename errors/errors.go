@@ -1,21 +1,81 @@
 package errors
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/windevkay/flhoutils/helpers"
+	"github.com/windevkay/flhoutils/validator"
 )
 
 // ErrorResponse writes an error response to the http.ResponseWriter.
 // It takes the http.ResponseWriter, http.Request, status code, and error message as input parameters.
-// It creates an envelope with the error message and writes it as JSON to the response writer.
+// It creates an envelope with the error message and writes it to the response writer in the media type
+// negotiated from the Accept header, which is JSON unless the client asks for another supported format.
+// When the problem details format is selected, the message is written as an RFC 9457 problem instead.
+// Every response is recorded through the logger registered with SetLogger.
 func ErrorResponse(w http.ResponseWriter, r *http.Request, status int, message any) {
+	errorResponse(w, r, status, message, nil, nil)
+}
+
+// errorResponse logs the response together with the underlying error, if any, and writes it.
+// Any additional fields are logged and written alongside the error message, or as problem extension members.
+func errorResponse(w http.ResponseWriter, r *http.Request, status int, message any, err error, fields helpers.Envelope) {
+	logError(w, r, status, message, err, fields)
+
+	if formatFor(r) == FormatProblem {
+		p := NewProblem(r, status, message)
+
+		if len(fields) > 0 && p.Extensions == nil {
+			p.Extensions = make(map[string]any, len(fields))
+		}
+
+		for key, value := range fields {
+			p.Extensions[key] = value
+		}
+
+		ProblemResponse(w, r, p)
+		return
+	}
+
 	env := helpers.Envelope{"error": message}
-	//write to logger service here - goroutine
-	//app.logger.Error(err.Error(), "method", r.Method, "uri", r.URL.RequestURI())
 
-	helpers.WriteJSON(w, status, env, nil)
+	for key, value := range fields {
+		env[key] = value
+	}
+
+	writeResponse(w, r, status, env, false)
+}
+
+// writeResponse writes the error body in the media type negotiated from the Accept header, using
+// JSON when nothing else is acceptable. Problem details are given the matching problem media type.
+// It falls back to an empty 500 Internal Server Error response if the body cannot be encoded.
+func writeResponse(w http.ResponseWriter, r *http.Request, status int, env helpers.Envelope, problem bool) {
+	mediaType, ok := helpers.NegotiateMediaType(r)
+	if !ok {
+		mediaType = "application/json"
+	}
+
+	var headers http.Header
+
+	if problem {
+		switch mediaType {
+		case "application/json":
+			headers = http.Header{"Content-Type": []string{"application/problem+json"}}
+		case "application/xml", "text/xml":
+			headers = http.Header{"Content-Type": []string{"application/problem+xml"}}
+		}
+	}
+
+	w.Header().Add("Vary", "Accept")
+
+	err := helpers.WriteEncoded(w, r, mediaType, status, env, headers)
+	if err != nil {
+		logError(w, r, http.StatusInternalServerError, nil, err, nil)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
 }
 
 // ServerErrorResponse sends a server error response to the client.
@@ -23,9 +83,17 @@ func ErrorResponse(w http.ResponseWriter, r *http.Request, status int, message a
 // The function sets the HTTP status code to 500 (Internal Server Error)
 // and sends a message indicating that the server encountered a problem
 // and could not process the request.
+// The response carries an incident ID that is logged with the full error, so reports can be matched to logs.
+// The error itself is only sent to the client in ModeDevelopment.
 func ServerErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
-	message := "The server encountered a problem and could not process your request: " + err.Error()
-	ErrorResponse(w, r, http.StatusInternalServerError, message)
+	message := "The server encountered a problem and could not process your request"
+
+	if mode == ModeDevelopment {
+		message += ": " + err.Error()
+	}
+
+	fields := helpers.Envelope{"incident_id": helpers.GenerateUniqueId(16)}
+	errorResponse(w, r, http.StatusInternalServerError, message, err, fields)
 }
 
 // NotFoundResponse sends a HTTP 404 Not Found response to the client with the specified message.
@@ -43,9 +111,63 @@ func MethodNotAllowedResponse(w http.ResponseWriter, r *http.Request) {
 	ErrorResponse(w, r, http.StatusMethodNotAllowed, message)
 }
 
+// NotAcceptableResponse sends a HTTP 406 Not Acceptable response to the client.
+// It is used when helpers.WriteResponse returns helpers.ErrNotAcceptable, and lists the media types the service can produce.
+// The response itself is written as JSON.
+func NotAcceptableResponse(w http.ResponseWriter, r *http.Request) {
+	message := "The requested resource is only available as " + strings.Join(helpers.SupportedMediaTypes(), ", ")
+	ErrorResponse(w, r, http.StatusNotAcceptable, message)
+}
+
 // BadRequestResponse sends a HTTP 400 Bad Request response with the given error message.
 func BadRequestResponse(w http.ResponseWriter, r *http.Request, err error) {
-	ErrorResponse(w, r, http.StatusBadRequest, err.Error())
+	errorResponse(w, r, http.StatusBadRequest, err.Error(), err, nil)
+}
+
+// PayloadTooLargeResponse sends a HTTP 413 Payload Too Large response with the given error message.
+// It is used when a request body exceeds the limit applied by http.MaxBytesReader.
+func PayloadTooLargeResponse(w http.ResponseWriter, r *http.Request, err error) {
+	errorResponse(w, r, http.StatusRequestEntityTooLarge, err.Error(), err, nil)
+}
+
+// UnsupportedMediaTypeResponse sends a HTTP 415 Unsupported Media Type response to the client.
+// It generates an error message naming the content type of the request that is not supported for the requested resource.
+func UnsupportedMediaTypeResponse(w http.ResponseWriter, r *http.Request) {
+	contentType := r.Header.Get("Content-Type")
+
+	message := "A Content-Type header is required for this resource"
+	if contentType != "" {
+		message = fmt.Sprintf("The %s content type is not supported for this resource", contentType)
+	}
+
+	ErrorResponse(w, r, http.StatusUnsupportedMediaType, message)
+}
+
+// DecodeErrorResponse sends the response matching an error returned by helpers.ReadJSON or helpers.DecodeJSON.
+// Bodies that are too large get a 413 Payload Too Large response, bodies sent with the wrong
+// content type a 415 Unsupported Media Type response, bodies that fail validation a 422 failed
+// validation response, and any other error a 400 Bad Request response.
+func DecodeErrorResponse(w http.ResponseWriter, r *http.Request, err error) {
+	var validationError *helpers.ValidationError
+	if errors.As(err, &validationError) {
+		FailedValidationResponse(w, r, validationError.Validator.Errors)
+		return
+	}
+
+	var jsonError *helpers.JSONError
+	if !errors.As(err, &jsonError) {
+		BadRequestResponse(w, r, err)
+		return
+	}
+
+	switch jsonError.Kind {
+	case helpers.JSONTooLarge:
+		PayloadTooLargeResponse(w, r, err)
+	case helpers.JSONContentType:
+		UnsupportedMediaTypeResponse(w, r)
+	default:
+		errorResponse(w, r, http.StatusBadRequest, jsonError.Error(), err, nil)
+	}
 }
 
 // FailedValidationResponse sends a failed validation response with the specified errors.
@@ -56,6 +178,13 @@ func FailedValidationResponse(w http.ResponseWriter, r *http.Request, errors map
 	ErrorResponse(w, r, http.StatusUnprocessableEntity, errors)
 }
 
+// FailedValidationFieldsResponse sends a failed validation response listing every message recorded for each field.
+// The fields are written in the order they failed validation, so the response body is deterministic.
+// The HTTP status code used is http.StatusUnprocessableEntity.
+func FailedValidationFieldsResponse(w http.ResponseWriter, r *http.Request, errors validator.FieldErrors) {
+	ErrorResponse(w, r, http.StatusUnprocessableEntity, errors)
+}
+
 // EditConflictResponse handles the response for an edit conflict (mainly arising from race conditions).
 // It sends an error response with the specified message and HTTP status code.
 func EditConflictResponse(w http.ResponseWriter, r *http.Request) {
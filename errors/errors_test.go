@@ -9,6 +9,10 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"testing"
+
+	"github.com/windevkay/flhoutils/assert"
+	"github.com/windevkay/flhoutils/helpers"
+	"github.com/windevkay/flhoutils/validator"
 )
 
 func testErrorResponse(t *testing.T, message string, status int) {
@@ -59,30 +63,43 @@ func TestErrorResponse(t *testing.T) {
 }
 
 func TestServerErrorResponse(t *testing.T) {
-	w := httptest.NewRecorder()
-	r := httptest.NewRequest(http.MethodGet, "/", nil)
-	ServerErrorResponse(w, r, errors.New("An error occured"))
-	resp := w.Result()
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusInternalServerError {
-		t.Errorf("Expected status code %d, but got %d", http.StatusInternalServerError, resp.StatusCode)
-	}
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		t.Errorf("Failed to read response body: %v", err)
-	}
-	var actualResponse map[string]interface{}
-	err = json.Unmarshal(body, &actualResponse)
-	if err != nil {
-		t.Fatalf("Failed to unmarshal response body: %v", err)
-	}
-	var expectedResponse map[string]interface{}
-	err = json.Unmarshal([]byte(`{"error": "The server encountered a problem and could not process your request: An error occured"}`), &expectedResponse)
-	if err != nil {
-		t.Fatalf("Failed to unmarshal expected response: %v", err)
+	tests := []struct {
+		name    string
+		mode    Mode
+		message string
+	}{
+		{name: "Production mode", mode: ModeProduction, message: "The server encountered a problem and could not process your request"},
+		{name: "Development mode", mode: ModeDevelopment, message: "The server encountered a problem and could not process your request: query failed: An error occured"},
 	}
-	if !reflect.DeepEqual(actualResponse, expectedResponse) {
-		t.Errorf("Expected response body %v, but got %v", expectedResponse, actualResponse)
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			SetMode(tc.mode)
+			defer SetMode(ModeProduction)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			ServerErrorResponse(w, r, fmt.Errorf("query failed: %w", errors.New("An error occured")))
+			resp := w.Result()
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusInternalServerError {
+				t.Errorf("Expected status code %d, but got %d", http.StatusInternalServerError, resp.StatusCode)
+			}
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Errorf("Failed to read response body: %v", err)
+			}
+			var actualResponse map[string]interface{}
+			err = json.Unmarshal(body, &actualResponse)
+			if err != nil {
+				t.Fatalf("Failed to unmarshal response body: %v", err)
+			}
+
+			incidentID, _ := actualResponse["incident_id"].(string)
+			assert.Equal(t, len(incidentID), 16)
+
+			assert.Equal(t, actualResponse["error"], interface{}(tc.message))
+		})
 	}
 }
 
@@ -368,3 +385,207 @@ func TestInactiveAccountResponseResponse(t *testing.T) {
 		t.Errorf("Expected response body %v, but got %v", expectedResponse, actualResponse)
 	}
 }
+
+func TestFailedValidationFieldsResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	v := validator.NewMulti()
+	v.AddError("field2", "cannot be empty")
+	v.AddError("field1", "cannot be empty")
+	v.AddError("field2", "should be more then 8 characters")
+	FailedValidationFieldsResponse(w, r, v.FieldErrors())
+	resp := w.Result()
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status code %d, but got %d", http.StatusUnprocessableEntity, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Errorf("Failed to read response body: %v", err)
+	}
+	expectedResponse := "{\n\t\"error\": {\n\t\t\"field2\": [\n\t\t\t\"cannot be empty\",\n\t\t\t\"should be more then 8 characters\"\n\t\t],\n\t\t\"field1\": [\n\t\t\t\"cannot be empty\"\n\t\t]\n\t}\n}\n"
+	if string(body) != expectedResponse {
+		t.Errorf("Expected response body %q, but got %q", expectedResponse, string(body))
+	}
+}
+
+func TestDecodeErrorResponse(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		message string
+	}{
+		{name: "Body too large", err: &helpers.JSONError{Kind: helpers.JSONTooLarge, Limit: 10}, status: http.StatusRequestEntityTooLarge, message: "body must not be larger than 10 bytes"},
+		{name: "Wrong content type", err: &helpers.JSONError{Kind: helpers.JSONContentType}, status: http.StatusUnsupportedMediaType, message: "A Content-Type header is required for this resource"},
+		{name: "Unknown field", err: &helpers.JSONError{Kind: helpers.JSONUnknownField, Field: "oddKey"}, status: http.StatusBadRequest, message: `body contains unknown key "oddKey"`},
+		{name: "Other error", err: errors.New("something else"), status: http.StatusBadRequest, message: "something else"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			DecodeErrorResponse(w, r, tc.err)
+			resp := w.Result()
+			defer resp.Body.Close()
+			if resp.StatusCode != tc.status {
+				t.Errorf("Expected status code %d, but got %d", tc.status, resp.StatusCode)
+			}
+			var actualResponse map[string]interface{}
+			err := json.NewDecoder(resp.Body).Decode(&actualResponse)
+			if err != nil {
+				t.Fatalf("Failed to unmarshal response body: %v", err)
+			}
+			if actualResponse["error"] != tc.message {
+				t.Errorf("Expected error message %q, but got %q", tc.message, actualResponse["error"])
+			}
+		})
+	}
+}
+
+func TestDecodeErrorResponseValidation(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	v := validator.New()
+	v.AddError("title", "must be provided")
+	DecodeErrorResponse(w, r, &helpers.ValidationError{Validator: v})
+	resp := w.Result()
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("Expected status code %d, but got %d", http.StatusUnprocessableEntity, resp.StatusCode)
+	}
+	var actualResponse map[string]interface{}
+	err := json.NewDecoder(resp.Body).Decode(&actualResponse)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	expectedResponse := map[string]interface{}{"error": map[string]interface{}{"title": "must be provided"}}
+	if !reflect.DeepEqual(actualResponse, expectedResponse) {
+		t.Errorf("Expected response body %v, but got %v", expectedResponse, actualResponse)
+	}
+}
+
+func TestPayloadTooLargeResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	PayloadTooLargeResponse(w, r, errors.New("body must not be larger than 1048576 bytes"))
+	resp := w.Result()
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status code %d, but got %d", http.StatusRequestEntityTooLarge, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Errorf("Failed to read response body: %v", err)
+	}
+	var actualResponse map[string]interface{}
+	err = json.Unmarshal(body, &actualResponse)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	var expectedResponse map[string]interface{}
+	err = json.Unmarshal([]byte(`{"error": "body must not be larger than 1048576 bytes"}`), &expectedResponse)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal expected response: %v", err)
+	}
+	if !reflect.DeepEqual(actualResponse, expectedResponse) {
+		t.Errorf("Expected response body %v, but got %v", expectedResponse, actualResponse)
+	}
+}
+
+func TestUnsupportedMediaTypeResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("Content-Type", "text/plain")
+	UnsupportedMediaTypeResponse(w, r)
+	resp := w.Result()
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("Expected status code %d, but got %d", http.StatusUnsupportedMediaType, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Errorf("Failed to read response body: %v", err)
+	}
+	var actualResponse map[string]interface{}
+	err = json.Unmarshal(body, &actualResponse)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	var expectedResponse map[string]interface{}
+	err = json.Unmarshal([]byte(`{"error": "The text/plain content type is not supported for this resource"}`), &expectedResponse)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal expected response: %v", err)
+	}
+	if !reflect.DeepEqual(actualResponse, expectedResponse) {
+		t.Errorf("Expected response body %v, but got %v", expectedResponse, actualResponse)
+	}
+}
+
+func TestErrorResponseUnsupportedValue(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	ErrorResponse(w, r, http.StatusBadRequest, map[string]interface{}{"field": make(chan int)})
+	resp := w.Result()
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected status code %d, but got %d", http.StatusInternalServerError, resp.StatusCode)
+	}
+}
+
+func TestErrorResponseNegotiation(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		format      Format
+		contentType string
+	}{
+		{name: "XML envelope", accept: "application/xml", format: FormatEnvelope, contentType: "application/xml"},
+		{name: "XML problem", accept: "application/xml", format: FormatProblem, contentType: "application/problem+xml"},
+		{name: "CBOR problem", accept: "application/cbor", format: FormatProblem, contentType: "application/cbor"},
+		{name: "JSON when nothing else is acceptable", accept: "text/html", format: FormatEnvelope, contentType: "application/json"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := WithFormat(httptest.NewRequest(http.MethodGet, "/", nil), tc.format)
+			r.Header.Set("Accept", tc.accept)
+			NotFoundResponse(w, r)
+			resp := w.Result()
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusNotFound {
+				t.Errorf("Expected status code %d, but got %d", http.StatusNotFound, resp.StatusCode)
+			}
+			if resp.Header.Get("Content-Type") != tc.contentType {
+				t.Errorf("Expected content type %q, but got %q", tc.contentType, resp.Header.Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestNotAcceptableResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "text/html")
+	NotAcceptableResponse(w, r)
+	resp := w.Result()
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNotAcceptable {
+		t.Errorf("Expected status code %d, but got %d", http.StatusNotAcceptable, resp.StatusCode)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Errorf("Failed to read response body: %v", err)
+	}
+	var actualResponse map[string]interface{}
+	err = json.Unmarshal(body, &actualResponse)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	expectedMessage := "The requested resource is only available as application/json, application/xml, text/xml, application/msgpack, application/x-msgpack, application/vnd.msgpack, application/cbor"
+	if actualResponse["error"] != expectedMessage {
+		t.Errorf("Expected error message %q, but got %q", expectedMessage, actualResponse["error"])
+	}
+}
@@ -0,0 +1,97 @@
+package errors
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/windevkay/flhoutils/helpers"
+)
+
+// RequestIDHeader is the header the request ID is read from when an error response is logged.
+// The incoming request is checked first, followed by the response headers.
+var RequestIDHeader = "X-Request-Id"
+
+var logger *slog.Logger
+
+// SetLogger registers the logger that records every error response.
+// Passing nil disables logging, which is the default, except for recovered panics,
+// which are then logged through slog.Default so they are never lost.
+func SetLogger(l *slog.Logger) {
+	logger = l
+}
+
+// logError records an error response with the request details.
+// Server errors (5xx) are logged at error level and client errors (4xx) at info level.
+// The stack trace of a recovered panic is included when the error is a helpers.PanicError,
+// and the messages of the wrapped errors are included as error_chain. Any fields are logged as they are.
+func logError(w http.ResponseWriter, r *http.Request, status int, message any, err error, fields helpers.Envelope) {
+	l := logger
+	if l == nil {
+		var panicError *helpers.PanicError
+		if !errors.As(err, &panicError) {
+			return
+		}
+
+		l = slog.Default()
+	}
+
+	level := slog.LevelInfo
+	if status >= http.StatusInternalServerError {
+		level = slog.LevelError
+	}
+
+	requestID := r.Header.Get(RequestIDHeader)
+	if requestID == "" {
+		requestID = w.Header().Get(RequestIDHeader)
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", r.Method),
+		slog.String("uri", r.URL.RequestURI()),
+		slog.Int("status", status),
+	}
+
+	if requestID != "" {
+		attrs = append(attrs, slog.String("request_id", requestID))
+	}
+
+	if err != nil {
+		attrs = append(attrs, slog.Any("error", err))
+
+		if chain := errorChain(err); len(chain) > 1 {
+			attrs = append(attrs, slog.Any("error_chain", chain))
+		}
+
+		var panicError *helpers.PanicError
+		if errors.As(err, &panicError) {
+			attrs = append(attrs, slog.String("stack", string(panicError.Stack)))
+		}
+	} else {
+		attrs = append(attrs, slog.Any("message", message))
+	}
+
+	for key, value := range fields {
+		attrs = append(attrs, slog.Any(key, value))
+	}
+
+	l.LogAttrs(r.Context(), level, http.StatusText(status), attrs...)
+}
+
+// errorChain returns the messages of the error and every error it wraps, depth first.
+func errorChain(err error) []string {
+	chain := []string{err.Error()}
+
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		if inner := e.Unwrap(); inner != nil {
+			chain = append(chain, errorChain(inner)...)
+		}
+	case interface{ Unwrap() []error }:
+		for _, inner := range e.Unwrap() {
+			chain = append(chain, errorChain(inner)...)
+		}
+	}
+
+	return chain
+}
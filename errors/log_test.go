@@ -0,0 +1,93 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/windevkay/flhoutils/assert"
+)
+
+func TestErrorResponseLogging(t *testing.T) {
+	tests := []struct {
+		name    string
+		respond func(w http.ResponseWriter, r *http.Request)
+		status  int
+		level   string
+		error   string
+	}{
+		{
+			name:    "Server errors are logged at error level",
+			respond: func(w http.ResponseWriter, r *http.Request) { ServerErrorResponse(w, r, errors.New("db down")) },
+			status:  http.StatusInternalServerError,
+			level:   "ERROR",
+			error:   "db down",
+		},
+		{
+			name:    "Client errors are logged at info level",
+			respond: NotFoundResponse,
+			status:  http.StatusNotFound,
+			level:   "INFO",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+			defer SetLogger(nil)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/movies?page=2", nil)
+			r.Header.Set("X-Request-Id", "abc123")
+			tc.respond(w, r)
+
+			var entry map[string]interface{}
+			err := json.Unmarshal(buf.Bytes(), &entry)
+			if err != nil {
+				t.Fatalf("Failed to unmarshal log entry: %v", err)
+			}
+
+			assert.Equal(t, entry["level"], interface{}(tc.level))
+			assert.Equal(t, entry["method"], interface{}(http.MethodGet))
+			assert.Equal(t, entry["uri"], interface{}("/movies?page=2"))
+			assert.Equal(t, entry["status"], interface{}(float64(tc.status)))
+			assert.Equal(t, entry["request_id"], interface{}("abc123"))
+
+			if tc.error != "" {
+				assert.Equal(t, entry["error"], interface{}(tc.error))
+			}
+		})
+	}
+}
+
+func TestServerErrorResponseIncidentID(t *testing.T) {
+	var buf bytes.Buffer
+	SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+	defer SetLogger(nil)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	ServerErrorResponse(w, r, fmt.Errorf("loading movie: %w", errors.New("pq: relation \"movies\" does not exist")))
+
+	var entry, body map[string]interface{}
+	err := json.Unmarshal(buf.Bytes(), &entry)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal log entry: %v", err)
+	}
+	err = json.Unmarshal(w.Body.Bytes(), &body)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+
+	assert.Equal(t, strings.Contains(w.Body.String(), "pq:"), false)
+	assert.Equal(t, entry["incident_id"], body["incident_id"])
+	assert.Equal(t, entry["error"], interface{}(`loading movie: pq: relation "movies" does not exist`))
+	assert.Equal(t, fmt.Sprint(entry["error_chain"]), `[loading movie: pq: relation "movies" does not exist pq: relation "movies" does not exist]`)
+}
@@ -0,0 +1,19 @@
+package errors
+
+// Mode controls how much detail server error responses reveal to clients.
+type Mode int
+
+const (
+	// ModeProduction sends clients a generic message and an incident ID; the error itself is only logged.
+	ModeProduction Mode = iota
+	// ModeDevelopment also includes the error text in server error responses.
+	ModeDevelopment
+)
+
+var mode = ModeProduction
+
+// SetMode sets the mode used by ServerErrorResponse. ModeProduction is the default.
+// It is intended to be called during startup.
+func SetMode(m Mode) {
+	mode = m
+}
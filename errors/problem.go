@@ -0,0 +1,110 @@
+package errors
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/windevkay/flhoutils/helpers"
+)
+
+// Format identifies the layout used for the body of an error response.
+type Format int
+
+const (
+	// FormatEnvelope writes errors as {"error": message}. This is the default.
+	FormatEnvelope Format = iota
+	// FormatProblem writes errors as RFC 9457 (formerly RFC 7807) application/problem+json documents.
+	FormatProblem
+)
+
+type formatContextKey struct{}
+
+var defaultFormat = FormatEnvelope
+
+// SetFormat sets the format used by ErrorResponse for every request of the service.
+// It is intended to be called once during startup.
+func SetFormat(format Format) {
+	defaultFormat = format
+}
+
+// WithFormat returns a copy of the request that overrides the service format for that request only.
+// It can be used by middleware to switch individual routes to problem details and back.
+func WithFormat(r *http.Request, format Format) *http.Request {
+	ctx := context.WithValue(r.Context(), formatContextKey{}, format)
+	return r.WithContext(ctx)
+}
+
+// formatFor returns the format that applies to the given request.
+func formatFor(r *http.Request) Format {
+	if format, ok := r.Context().Value(formatContextKey{}).(Format); ok {
+		return format
+	}
+
+	return defaultFormat
+}
+
+// Problem holds the members of an RFC 9457 problem details object.
+// Extensions are written alongside the standard members, which always take precedence.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+}
+
+// NewProblem creates a Problem for the given request and status.
+// A string message becomes the detail member; any other message, such as a map of
+// validation errors, is exposed through the "errors" extension member.
+func NewProblem(r *http.Request, status int, message any) Problem {
+	p := Problem{
+		Type:     "about:blank",
+		Title:    http.StatusText(status),
+		Status:   status,
+		Instance: r.URL.RequestURI(),
+	}
+
+	switch m := message.(type) {
+	case string:
+		p.Detail = m
+	case nil:
+	default:
+		p.Extensions = map[string]any{"errors": m}
+	}
+
+	return p
+}
+
+// envelope flattens the problem into a single JSON object.
+func (p Problem) envelope() helpers.Envelope {
+	env := helpers.Envelope{}
+
+	for key, value := range p.Extensions {
+		env[key] = value
+	}
+
+	env["type"] = p.Type
+	env["title"] = p.Title
+	env["status"] = p.Status
+
+	if p.Detail != "" {
+		env["detail"] = p.Detail
+	}
+
+	if p.Instance != "" {
+		env["instance"] = p.Instance
+	}
+
+	return env
+}
+
+// ProblemResponse writes the problem as an application/problem+json response, or application/problem+xml
+// when the client asks for XML, regardless of the format configured for the service.
+func ProblemResponse(w http.ResponseWriter, r *http.Request, p Problem) {
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+
+	writeResponse(w, r, p.Status, p.envelope(), true)
+}
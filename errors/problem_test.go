@@ -0,0 +1,94 @@
+package errors
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/windevkay/flhoutils/assert"
+)
+
+func decodeProblem(t *testing.T, resp *http.Response) map[string]interface{} {
+	t.Helper()
+
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Failed to read response body: %v", err)
+	}
+	var actualResponse map[string]interface{}
+	err = json.Unmarshal(body, &actualResponse)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal response body: %v", err)
+	}
+	return actualResponse
+}
+
+func TestProblemFormatForService(t *testing.T) {
+	SetFormat(FormatProblem)
+	defer SetFormat(FormatEnvelope)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/movies/1?x=y", nil)
+	NotFoundResponse(w, r)
+	resp := w.Result()
+
+	assert.Equal(t, resp.StatusCode, http.StatusNotFound)
+	assert.Equal(t, resp.Header.Get("Content-Type"), "application/problem+json")
+
+	expectedResponse := map[string]interface{}{
+		"type":     "about:blank",
+		"title":    "Not Found",
+		"status":   float64(http.StatusNotFound),
+		"detail":   "The requested resource could not be found",
+		"instance": "/movies/1?x=y",
+	}
+	actualResponse := decodeProblem(t, resp)
+	if !reflect.DeepEqual(actualResponse, expectedResponse) {
+		t.Errorf("Expected response body %v, but got %v", expectedResponse, actualResponse)
+	}
+}
+
+func TestProblemFormatPerRequest(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := WithFormat(httptest.NewRequest(http.MethodPost, "/movies", nil), FormatProblem)
+	FailedValidationResponse(w, r, map[string]string{"title": "must be provided"})
+	resp := w.Result()
+
+	assert.Equal(t, resp.StatusCode, http.StatusUnprocessableEntity)
+	assert.Equal(t, resp.Header.Get("Content-Type"), "application/problem+json")
+
+	expectedResponse := map[string]interface{}{
+		"type":     "about:blank",
+		"title":    "Unprocessable Entity",
+		"status":   float64(http.StatusUnprocessableEntity),
+		"instance": "/movies",
+		"errors":   map[string]interface{}{"title": "must be provided"},
+	}
+	actualResponse := decodeProblem(t, resp)
+	if !reflect.DeepEqual(actualResponse, expectedResponse) {
+		t.Errorf("Expected response body %v, but got %v", expectedResponse, actualResponse)
+	}
+}
+
+func TestProblemResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	ProblemResponse(w, r, Problem{
+		Type:       "https://example.com/probs/out-of-credit",
+		Title:      "You do not have enough credit.",
+		Status:     http.StatusForbidden,
+		Extensions: map[string]any{"balance": 30, "status": 200},
+	})
+	resp := w.Result()
+
+	assert.Equal(t, resp.StatusCode, http.StatusForbidden)
+
+	actualResponse := decodeProblem(t, resp)
+	assert.Equal(t, actualResponse["type"], interface{}("https://example.com/probs/out-of-credit"))
+	assert.Equal(t, actualResponse["balance"], interface{}(float64(30)))
+	assert.Equal(t, actualResponse["status"], interface{}(float64(http.StatusForbidden)))
+}
@@ -0,0 +1,128 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/windevkay/flhoutils/validator"
+)
+
+// ErrInvalidCursor is returned when a cursor is malformed or its signature does not match.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// CursorCodec encodes and decodes opaque cursors for keyset pagination.
+// A cursor holds the JSON encoded position of a record, such as its sort key and ID,
+// signed with HMAC-SHA256 so that clients cannot tamper with it.
+type CursorCodec struct {
+	secret []byte
+}
+
+// CursorMetadata describes the page of records returned by a keyset-paginated list endpoint.
+// It is meant to be placed in the response Envelope alongside the records.
+type CursorMetadata struct {
+	Limit      int    `json:"limit,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	PrevCursor string `json:"prev_cursor,omitempty"`
+}
+
+// minCursorSecretLength is the shortest secret NewCursorCodec accepts, the size of a SHA-256 key.
+const minCursorSecretLength = 32
+
+// NewCursorCodec creates a CursorCodec that signs cursors with the given secret.
+// The secret should be random and must be at least 32 bytes long; NewCursorCodec panics if it is shorter.
+func NewCursorCodec(secret []byte) *CursorCodec {
+	if len(secret) < minCursorSecretLength {
+		panic(fmt.Sprintf("helpers: cursor secret must be at least %d bytes, got %d", minCursorSecretLength, len(secret)))
+	}
+
+	return &CursorCodec{secret: secret}
+}
+
+// Encode returns an opaque cursor for the given position.
+func (c *CursorCodec) Encode(position any) (string, error) {
+	payload, err := json.Marshal(position)
+	if err != nil {
+		return "", err
+	}
+
+	encoding := base64.RawURLEncoding
+
+	return encoding.EncodeToString(payload) + "." + encoding.EncodeToString(c.sign(payload)), nil
+}
+
+// Decode verifies the cursor and decodes its position into dst.
+// It returns ErrInvalidCursor if the cursor is malformed or has been tampered with.
+func (c *CursorCodec) Decode(cursor string, dst any) error {
+	encodedPayload, encodedSignature, found := strings.Cut(cursor, ".")
+	if !found {
+		return ErrInvalidCursor
+	}
+
+	encoding := base64.RawURLEncoding
+
+	payload, err := encoding.DecodeString(encodedPayload)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+
+	signature, err := encoding.DecodeString(encodedSignature)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+
+	if !hmac.Equal(signature, c.sign(payload)) {
+		return ErrInvalidCursor
+	}
+
+	err = json.Unmarshal(payload, dst)
+	if err != nil {
+		return ErrInvalidCursor
+	}
+
+	return nil
+}
+
+// sign returns the HMAC-SHA256 signature of the payload.
+func (c *CursorCodec) sign(payload []byte) []byte {
+	mac := hmac.New(sha256.New, c.secret)
+	mac.Write(payload)
+
+	return mac.Sum(nil)
+}
+
+// ReadCursor reads a cursor from the given URL query string parameter and decodes its position into dst.
+// It returns true if a valid cursor was present. If the parameter is not present, dst is left unchanged.
+// If the cursor is invalid, it adds an error to the validator.
+func ReadCursor(qs url.Values, key string, c *CursorCodec, dst any, v *validator.Validator) bool {
+	s := qs.Get(key)
+
+	if s == "" {
+		return false
+	}
+
+	err := c.Decode(s, dst)
+	if err != nil {
+		v.AddError(key, "must be a valid cursor")
+		return false
+	}
+
+	return true
+}
+
+// ReadLimit reads the page size of a keyset-paginated list from the given URL query string parameter.
+// It returns defaultValue when the parameter is not present and reports values outside 1 to maxValue to the validator.
+func ReadLimit(qs url.Values, key string, defaultValue, maxValue int, v *validator.Validator) int {
+	limit := ReadInt(qs, key, defaultValue, v)
+
+	v.Check(limit > 0, key, "must be greater than zero")
+	v.Check(limit <= maxValue, key, "must be a maximum of "+strconv.Itoa(maxValue))
+
+	return limit
+}
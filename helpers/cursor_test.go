@@ -0,0 +1,123 @@
+package helpers
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/windevkay/flhoutils/assert"
+	"github.com/windevkay/flhoutils/validator"
+)
+
+type moviePosition struct {
+	CreatedAt string `json:"created_at"`
+	ID        int64  `json:"id"`
+}
+
+var (
+	cursorSecret      = []byte("0123456789abcdef0123456789abcdef")
+	otherCursorSecret = []byte("fedcba9876543210fedcba9876543210")
+)
+
+func TestCursorCodec(t *testing.T) {
+	codec := NewCursorCodec(cursorSecret)
+
+	cursor, err := codec.Encode(moviePosition{CreatedAt: "2024-01-01T00:00:00Z", ID: 42})
+	if err != nil {
+		t.Fatalf("Failed to encode cursor: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		codec  *CursorCodec
+		cursor string
+		err    error
+	}{
+		{name: "Valid cursor", codec: codec, cursor: cursor, err: nil},
+		{name: "Tampered cursor", codec: codec, cursor: "eyJpZCI6MX0" + cursor[strings.Index(cursor, "."):], err: ErrInvalidCursor},
+		{name: "Cursor signed with another secret", codec: NewCursorCodec(otherCursorSecret), cursor: cursor, err: ErrInvalidCursor},
+		{name: "Malformed cursor", codec: codec, cursor: "not-a-cursor", err: ErrInvalidCursor},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var position moviePosition
+			err := tc.codec.Decode(tc.cursor, &position)
+
+			assert.Equal(t, errors.Is(err, tc.err), true)
+			if tc.err == nil {
+				assert.Equal(t, position.ID, int64(42))
+			}
+		})
+	}
+}
+
+func TestNewCursorCodecPanicsOnShortSecret(t *testing.T) {
+	for _, secret := range [][]byte{nil, []byte("secret"), cursorSecret[:31]} {
+		func() {
+			defer func() {
+				assert.Equal(t, recover() != nil, true)
+			}()
+
+			NewCursorCodec(secret)
+		}()
+	}
+}
+
+func TestReadCursor(t *testing.T) {
+	codec := NewCursorCodec(cursorSecret)
+	cursor, _ := codec.Encode(moviePosition{ID: 7})
+
+	tests := []struct {
+		name  string
+		value string
+		found bool
+		valid bool
+	}{
+		{name: "Valid cursor", value: cursor, found: true, valid: true},
+		{name: "Missing cursor", value: "", found: false, valid: true},
+		{name: "Invalid cursor", value: "abc.def", found: false, valid: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			qs := url.Values{}
+			qs.Add("cursor", tc.value)
+
+			var position moviePosition
+			v := validator.New()
+			found := ReadCursor(qs, "cursor", codec, &position, v)
+
+			assert.Equal(t, found, tc.found)
+			assert.Equal(t, v.Valid(), tc.valid)
+		})
+	}
+}
+
+func TestReadLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  int
+		valid bool
+	}{
+		{name: "Default limit", value: "", want: 20, valid: true},
+		{name: "Provided limit", value: "50", want: 50, valid: true},
+		{name: "Limit above maximum", value: "500", want: 500, valid: false},
+		{name: "Limit below one", value: "0", want: 0, valid: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			qs := url.Values{}
+			qs.Add("limit", tc.value)
+
+			v := validator.New()
+			limit := ReadLimit(qs, "limit", 20, 100, v)
+
+			assert.Equal(t, limit, tc.want)
+			assert.Equal(t, v.Valid(), tc.valid)
+		})
+	}
+}
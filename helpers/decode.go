@@ -0,0 +1,65 @@
+package helpers
+
+import (
+	"net/http"
+	"reflect"
+
+	"github.com/windevkay/flhoutils/validator"
+)
+
+// ValidationError is returned by DecodeJSON when the decoded value fails validation.
+// Validator holds the recorded errors.
+type ValidationError struct {
+	Validator *validator.Validator
+}
+
+// Error returns a summary of the failure; the field errors are held by the validator.
+func (e *ValidationError) Error() string {
+	return "body failed validation"
+}
+
+// DecodeJSON reads the request body into a new value of type T using ReadJSON and validates it.
+// If *T implements validator.Validatable, its Validate method is called; otherwise a struct T is
+// checked against its `validate` tags with validator.ValidateStruct.
+// It returns a *JSONError if the body cannot be decoded and a *ValidationError if validation fails.
+func DecodeJSON[T any](w http.ResponseWriter, r *http.Request) (T, error) {
+	return DecodeJSONWithOptions[T](w, r, ReadJSONOptions{})
+}
+
+// DecodeJSONWithOptions works like DecodeJSON, reading the body with ReadJSONWithOptions.
+func DecodeJSONWithOptions[T any](w http.ResponseWriter, r *http.Request, opts ReadJSONOptions) (T, error) {
+	var dst T
+
+	err := ReadJSONWithOptions(w, r, &dst, opts)
+	if err != nil {
+		return dst, err
+	}
+
+	v := validator.New()
+	validate(v, &dst)
+
+	if !v.Valid() {
+		return dst, &ValidationError{Validator: v}
+	}
+
+	return dst, nil
+}
+
+// validate checks the value pointed to by ptr, following pointers until a
+// validator.Validatable or a struct is found.
+func validate(v *validator.Validator, ptr any) {
+	rv := reflect.ValueOf(ptr)
+
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		if value, ok := rv.Interface().(validator.Validatable); ok {
+			value.Validate(v)
+			return
+		}
+
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() == reflect.Struct {
+		validator.ValidateStruct(v, rv.Interface())
+	}
+}
@@ -0,0 +1,94 @@
+package helpers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/windevkay/flhoutils/assert"
+	"github.com/windevkay/flhoutils/validator"
+)
+
+type taggedInput struct {
+	Title string `json:"title" validate:"required"`
+}
+
+type selfValidatingInput struct {
+	Year int `json:"year"`
+}
+
+func (in *selfValidatingInput) Validate(v *validator.Validator) {
+	v.Check(in.Year >= 1888, "year", "must be greater than 1888")
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		decode func(w http.ResponseWriter, r *http.Request) error
+		body   string
+		key    string
+		msg    string
+	}{
+		{
+			name:   "Struct tags pass",
+			decode: decodeWith[taggedInput],
+			body:   `{"title": "Up"}`,
+		},
+		{
+			name:   "Struct tags fail",
+			decode: decodeWith[taggedInput],
+			body:   `{}`,
+			key:    "title",
+			msg:    "must be provided",
+		},
+		{
+			name:   "Validate method fails",
+			decode: decodeWith[selfValidatingInput],
+			body:   `{"year": 1700}`,
+			key:    "year",
+			msg:    "must be greater than 1888",
+		},
+		{
+			name:   "Validate method on pointer type fails",
+			decode: decodeWith[*selfValidatingInput],
+			body:   `{"year": 1700}`,
+			key:    "year",
+			msg:    "must be greater than 1888",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+
+			err := tc.decode(w, r)
+
+			if tc.key == "" {
+				assert.Equal(t, err, nil)
+				return
+			}
+
+			var validationError *ValidationError
+			assert.Equal(t, errors.As(err, &validationError), true)
+			assert.Equal(t, validationError.Validator.Errors[tc.key], tc.msg)
+		})
+	}
+}
+
+func TestDecodeJSONReturnsValue(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"title": "Up"}`))
+
+	input, err := DecodeJSON[taggedInput](w, r)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, input.Title, "Up")
+}
+
+func decodeWith[T any](w http.ResponseWriter, r *http.Request) error {
+	_, err := DecodeJSON[T](w, r)
+	return err
+}
@@ -0,0 +1,411 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// The built-in XML, MessagePack and CBOR encoders first encode values as JSON, so struct tags and
+// json.Marshaler implementations apply to every format, and then re-encode the generic result.
+// Objects are decoded into members in their original order.
+
+// member is a key/value pair of a JSON object, kept in the order it was encoded.
+type member struct {
+	key   string
+	value any
+}
+
+// object is a JSON object whose members keep their order.
+type object []member
+
+// xmlNameRX matches keys that can be used as XML element names as they are.
+var xmlNameRX = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9._-]*$`)
+
+// toGeneric encodes the value as JSON and decodes it into objects, []any, json.Number, string, bool and nil values.
+func toGeneric(v any) (any, error) {
+	js, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(js))
+	dec.UseNumber()
+
+	return decodeGeneric(dec)
+}
+
+// decodeGeneric decodes the next JSON value from the decoder's token stream.
+func decodeGeneric(dec *json.Decoder) (any, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case json.Delim('{'):
+		var obj object
+
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := decodeGeneric(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			obj = append(obj, member{key: key.(string), value: value})
+		}
+
+		_, err = dec.Token()
+		return obj, err
+
+	case json.Delim('['):
+		list := []any{}
+
+		for dec.More() {
+			value, err := decodeGeneric(dec)
+			if err != nil {
+				return nil, err
+			}
+
+			list = append(list, value)
+		}
+
+		_, err = dec.Token()
+		return list, err
+
+	default:
+		return token, nil
+	}
+}
+
+// encodeXML writes the value as an XML document with a <response> root element.
+// Object members become child elements named after their keys, or <entry key="..."> elements
+// when a key is not a valid element name, and list items become <item> elements.
+func encodeXML(w io.Writer, v any) error {
+	value, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+
+	err = writeXMLElement(&buf, "response", "", value)
+	if err != nil {
+		return err
+	}
+
+	buf.WriteByte('\n')
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// writeXMLElement writes a single element and its content.
+func writeXMLElement(buf *bytes.Buffer, name, key string, value any) error {
+	buf.WriteString("<" + name)
+
+	if key != "" {
+		buf.WriteString(` key="`)
+		err := xml.EscapeText(buf, []byte(key))
+		if err != nil {
+			return err
+		}
+		buf.WriteByte('"')
+	}
+
+	if value == nil {
+		buf.WriteString("/>")
+		return nil
+	}
+
+	buf.WriteByte('>')
+
+	var err error
+
+	switch value := value.(type) {
+	case object:
+		for _, m := range value {
+			if xmlNameRX.MatchString(m.key) {
+				err = writeXMLElement(buf, m.key, "", m.value)
+			} else {
+				err = writeXMLElement(buf, "entry", m.key, m.value)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+	case []any:
+		for _, item := range value {
+			err = writeXMLElement(buf, "item", "", item)
+			if err != nil {
+				return err
+			}
+		}
+
+	case string:
+		err = xml.EscapeText(buf, []byte(value))
+
+	case json.Number:
+		buf.WriteString(value.String())
+
+	case bool:
+		buf.WriteString(strconv.FormatBool(value))
+	}
+
+	if err != nil {
+		return err
+	}
+
+	buf.WriteString("</" + name + ">")
+	return nil
+}
+
+// encodeMessagePack writes the value in the MessagePack format.
+func encodeMessagePack(w io.Writer, v any) error {
+	value, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	err = writeMessagePack(&buf, value)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// writeMessagePack appends the MessagePack encoding of a generic value.
+func writeMessagePack(buf *bytes.Buffer, value any) error {
+	switch value := value.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+
+	case bool:
+		if value {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			writeMessagePackInt(buf, i)
+			return nil
+		}
+
+		if u, err := strconv.ParseUint(value.String(), 10, 64); err == nil {
+			buf.WriteByte(0xcf)
+			buf.Write(binary.BigEndian.AppendUint64(nil, u))
+			return nil
+		}
+
+		f, err := value.Float64()
+		if err != nil {
+			return err
+		}
+
+		buf.WriteByte(0xcb)
+		buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+
+	case string:
+		writeMessagePackHeader(buf, len(value), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		buf.WriteString(value)
+
+	case []any:
+		writeMessagePackHeader(buf, len(value), 0x90, 16, 0, 0xdc, 0xdd)
+
+		for _, item := range value {
+			err := writeMessagePack(buf, item)
+			if err != nil {
+				return err
+			}
+		}
+
+	case object:
+		writeMessagePackHeader(buf, len(value), 0x80, 16, 0, 0xde, 0xdf)
+
+		for _, m := range value {
+			err := writeMessagePack(buf, m.key)
+			if err != nil {
+				return err
+			}
+
+			err = writeMessagePack(buf, m.value)
+			if err != nil {
+				return err
+			}
+		}
+
+	default:
+		return errors.New("messagepack: unsupported value")
+	}
+
+	return nil
+}
+
+// writeMessagePackInt appends the smallest MessagePack encoding of an integer.
+func writeMessagePackInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i <= math.MaxInt8:
+		buf.WriteByte(byte(i))
+	case i < 0 && i >= -32:
+		buf.WriteByte(byte(int8(i)))
+	case i >= math.MinInt8 && i <= math.MaxInt8:
+		buf.Write([]byte{0xd0, byte(int8(i))})
+	case i >= math.MinInt16 && i <= math.MaxInt16:
+		buf.WriteByte(0xd1)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(int16(i))))
+	case i >= math.MinInt32 && i <= math.MaxInt32:
+		buf.WriteByte(0xd2)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(int32(i))))
+	default:
+		buf.WriteByte(0xd3)
+		buf.Write(binary.BigEndian.AppendUint64(nil, uint64(i)))
+	}
+}
+
+// writeMessagePackHeader appends the type and length of a string, array or map.
+// Lengths below fixLimit use the fix format; a zero code8 means the type has no 8-bit length format.
+func writeMessagePackHeader(buf *bytes.Buffer, n int, fix byte, fixLimit int, code8, code16, code32 byte) {
+	switch {
+	case n < fixLimit:
+		buf.WriteByte(fix | byte(n))
+	case code8 != 0 && n <= math.MaxUint8:
+		buf.Write([]byte{code8, byte(n)})
+	case n <= math.MaxUint16:
+		buf.WriteByte(code16)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		buf.WriteByte(code32)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
+
+// encodeCBOR writes the value in the CBOR format (RFC 8949).
+func encodeCBOR(w io.Writer, v any) error {
+	value, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+
+	err = writeCBOR(&buf, value)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// writeCBOR appends the CBOR encoding of a generic value.
+func writeCBOR(buf *bytes.Buffer, value any) error {
+	switch value := value.(type) {
+	case nil:
+		buf.WriteByte(0xf6)
+
+	case bool:
+		if value {
+			buf.WriteByte(0xf5)
+		} else {
+			buf.WriteByte(0xf4)
+		}
+
+	case json.Number:
+		if i, err := value.Int64(); err == nil {
+			if i >= 0 {
+				writeCBORHeader(buf, 0, uint64(i))
+			} else {
+				writeCBORHeader(buf, 1, uint64(-1-i))
+			}
+			return nil
+		}
+
+		if u, err := strconv.ParseUint(value.String(), 10, 64); err == nil {
+			writeCBORHeader(buf, 0, u)
+			return nil
+		}
+
+		f, err := value.Float64()
+		if err != nil {
+			return err
+		}
+
+		buf.WriteByte(0xfb)
+		buf.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+
+	case string:
+		writeCBORHeader(buf, 3, uint64(len(value)))
+		buf.WriteString(value)
+
+	case []any:
+		writeCBORHeader(buf, 4, uint64(len(value)))
+
+		for _, item := range value {
+			err := writeCBOR(buf, item)
+			if err != nil {
+				return err
+			}
+		}
+
+	case object:
+		writeCBORHeader(buf, 5, uint64(len(value)))
+
+		for _, m := range value {
+			err := writeCBOR(buf, m.key)
+			if err != nil {
+				return err
+			}
+
+			err = writeCBOR(buf, m.value)
+			if err != nil {
+				return err
+			}
+		}
+
+	default:
+		return errors.New("cbor: unsupported value")
+	}
+
+	return nil
+}
+
+// writeCBORHeader appends the initial byte of a data item with the given major type and argument.
+func writeCBORHeader(buf *bytes.Buffer, major byte, n uint64) {
+	major <<= 5
+
+	switch {
+	case n < 24:
+		buf.WriteByte(major | byte(n))
+	case n <= math.MaxUint8:
+		buf.Write([]byte{major | 24, byte(n)})
+	case n <= math.MaxUint16:
+		buf.WriteByte(major | 25)
+		buf.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	case n <= math.MaxUint32:
+		buf.WriteByte(major | 26)
+		buf.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	default:
+		buf.WriteByte(major | 27)
+		buf.Write(binary.BigEndian.AppendUint64(nil, n))
+	}
+}
@@ -0,0 +1,66 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/windevkay/flhoutils/assert"
+)
+
+type encodedMovie struct {
+	Title  string   `json:"title"`
+	Year   int      `json:"year"`
+	Rating float64  `json:"rating"`
+	Genres []string `json:"genres"`
+	Sequel *string  `json:"sequel"`
+	Draft  bool     `json:"draft"`
+}
+
+var movieEnvelope = Envelope{"movie": encodedMovie{Title: "Up & Away", Year: 2009, Rating: 8.5, Genres: []string{"animation"}, Draft: true}, "offset": -40}
+
+func TestEncodeXML(t *testing.T) {
+	var buf bytes.Buffer
+	err := encodeXML(&buf, Envelope{"movie": movieEnvelope["movie"], "2nd key": "x"})
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, buf.String(), `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+
+		`<response><entry key="2nd key">x</entry><movie><title>Up &amp; Away</title><year>2009</year><rating>8.5</rating>`+
+		`<genres><item>animation</item></genres><sequel/><draft>true</draft></movie></response>`+"\n")
+}
+
+func TestEncodeMessagePack(t *testing.T) {
+	var buf bytes.Buffer
+	err := encodeMessagePack(&buf, movieEnvelope)
+
+	want := "82" + // map of 2
+		"a5" + hex.EncodeToString([]byte("movie")) + "86" + // map of 6
+		"a5" + hex.EncodeToString([]byte("title")) + "a9" + hex.EncodeToString([]byte("Up & Away")) +
+		"a4" + hex.EncodeToString([]byte("year")) + "d107d9" +
+		"a6" + hex.EncodeToString([]byte("rating")) + "cb4021000000000000" +
+		"a6" + hex.EncodeToString([]byte("genres")) + "91a9" + hex.EncodeToString([]byte("animation")) +
+		"a6" + hex.EncodeToString([]byte("sequel")) + "c0" +
+		"a5" + hex.EncodeToString([]byte("draft")) + "c3" +
+		"a6" + hex.EncodeToString([]byte("offset")) + "d0d8"
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, hex.EncodeToString(buf.Bytes()), want)
+}
+
+func TestEncodeCBOR(t *testing.T) {
+	var buf bytes.Buffer
+	err := encodeCBOR(&buf, movieEnvelope)
+
+	want := "a2" + // map of 2
+		"65" + hex.EncodeToString([]byte("movie")) + "a6" + // map of 6
+		"65" + hex.EncodeToString([]byte("title")) + "69" + hex.EncodeToString([]byte("Up & Away")) +
+		"64" + hex.EncodeToString([]byte("year")) + "1907d9" +
+		"66" + hex.EncodeToString([]byte("rating")) + "fb4021000000000000" +
+		"66" + hex.EncodeToString([]byte("genres")) + "8169" + hex.EncodeToString([]byte("animation")) +
+		"66" + hex.EncodeToString([]byte("sequel")) + "f6" +
+		"65" + hex.EncodeToString([]byte("draft")) + "f5" +
+		"66" + hex.EncodeToString([]byte("offset")) + "3827"
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, hex.EncodeToString(buf.Bytes()), want)
+}
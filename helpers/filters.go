@@ -0,0 +1,98 @@
+package helpers
+
+import (
+	"math"
+	"net/url"
+	"strings"
+
+	"github.com/windevkay/flhoutils/validator"
+)
+
+const (
+	maxPage     = 10_000_000
+	maxPageSize = 100
+)
+
+// Filters holds the pagination and sorting parameters of a list endpoint.
+// SortSafelist lists the permitted sort values; a leading "-" requests descending order.
+type Filters struct {
+	Page         int
+	PageSize     int
+	Sort         string
+	SortSafelist []string
+}
+
+// Metadata describes the page of records returned by a list endpoint.
+// It is meant to be placed in the response Envelope alongside the records.
+type Metadata struct {
+	CurrentPage  int `json:"current_page,omitempty"`
+	PageSize     int `json:"page_size,omitempty"`
+	FirstPage    int `json:"first_page,omitempty"`
+	LastPage     int `json:"last_page,omitempty"`
+	TotalRecords int `json:"total_records,omitempty"`
+}
+
+// ReadFilters reads the page, page_size and sort parameters from the query string.
+// Page defaults to 1, page_size to 20 and sort to defaultSort. Parse errors are added to the validator.
+func ReadFilters(qs url.Values, defaultSort string, sortSafelist []string, v *validator.Validator) Filters {
+	return Filters{
+		Page:         ReadInt(qs, "page", 1, v),
+		PageSize:     ReadInt(qs, "page_size", 20, v),
+		Sort:         ReadString(qs, "sort", defaultSort),
+		SortSafelist: sortSafelist,
+	}
+}
+
+// ValidateFilters checks that the page and page size are within bounds and that the sort value is permitted.
+func ValidateFilters(v *validator.Validator, f Filters) {
+	v.Check(f.Page > 0, "page", "must be greater than zero")
+	v.Check(f.Page <= maxPage, "page", "must be a maximum of 10 million")
+	v.Check(f.PageSize > 0, "page_size", "must be greater than zero")
+	v.Check(f.PageSize <= maxPageSize, "page_size", "must be a maximum of 100")
+	v.Check(validator.PermittedValue(f.Sort, f.SortSafelist...), "sort", "invalid sort value")
+}
+
+// SortColumn returns the column name to sort by, without the direction prefix.
+// It panics if the sort value is not in the safelist, so the result is always safe to interpolate into SQL.
+func (f Filters) SortColumn() string {
+	if validator.PermittedValue(f.Sort, f.SortSafelist...) {
+		return strings.TrimPrefix(f.Sort, "-")
+	}
+
+	panic("unsafe sort parameter: " + f.Sort)
+}
+
+// SortDirection returns "DESC" when the sort value has a "-" prefix and "ASC" otherwise.
+func (f Filters) SortDirection() string {
+	if strings.HasPrefix(f.Sort, "-") {
+		return "DESC"
+	}
+
+	return "ASC"
+}
+
+// Limit returns the number of records to fetch for the page.
+func (f Filters) Limit() int {
+	return f.PageSize
+}
+
+// Offset returns the number of records to skip to reach the page.
+func (f Filters) Offset() int {
+	return (f.Page - 1) * f.PageSize
+}
+
+// CalculateMetadata returns the pagination metadata for the given total number of records.
+// An empty Metadata is returned when there are no records.
+func CalculateMetadata(totalRecords, page, pageSize int) Metadata {
+	if totalRecords == 0 {
+		return Metadata{}
+	}
+
+	return Metadata{
+		CurrentPage:  page,
+		PageSize:     pageSize,
+		FirstPage:    1,
+		LastPage:     int(math.Ceil(float64(totalRecords) / float64(pageSize))),
+		TotalRecords: totalRecords,
+	}
+}
@@ -0,0 +1,75 @@
+package helpers
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/windevkay/flhoutils/assert"
+	"github.com/windevkay/flhoutils/validator"
+)
+
+func TestReadFilters(t *testing.T) {
+	safelist := []string{"id", "title", "-id", "-title"}
+
+	tests := []struct {
+		name      string
+		query     string
+		valid     bool
+		column    string
+		direction string
+		limit     int
+		offset    int
+	}{
+		{name: "Defaults", query: "", valid: true, column: "id", direction: "ASC", limit: 20, offset: 0},
+		{name: "Descending sort on a later page", query: "page=3&page_size=5&sort=-title", valid: true, column: "title", direction: "DESC", limit: 5, offset: 10},
+		{name: "Unsafe sort", query: "sort=password", valid: false},
+		{name: "Page size out of bounds", query: "page_size=101", valid: false},
+		{name: "Page must be positive", query: "page=0", valid: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			qs, _ := url.ParseQuery(tc.query)
+			v := validator.New()
+
+			f := ReadFilters(qs, "id", safelist, v)
+			ValidateFilters(v, f)
+
+			assert.Equal(t, v.Valid(), tc.valid)
+
+			if tc.valid {
+				assert.Equal(t, f.SortColumn(), tc.column)
+				assert.Equal(t, f.SortDirection(), tc.direction)
+				assert.Equal(t, f.Limit(), tc.limit)
+				assert.Equal(t, f.Offset(), tc.offset)
+			}
+		})
+	}
+}
+
+func TestSortColumnPanicsOnUnsafeSort(t *testing.T) {
+	defer func() {
+		assert.Equal(t, recover() != nil, true)
+	}()
+
+	Filters{Sort: "password", SortSafelist: []string{"id"}}.SortColumn()
+}
+
+func TestCalculateMetadata(t *testing.T) {
+	tests := []struct {
+		name         string
+		totalRecords int
+		page         int
+		pageSize     int
+		want         Metadata
+	}{
+		{name: "No records", totalRecords: 0, page: 1, pageSize: 20, want: Metadata{}},
+		{name: "Partial last page", totalRecords: 45, page: 2, pageSize: 20, want: Metadata{CurrentPage: 2, PageSize: 20, FirstPage: 1, LastPage: 3, TotalRecords: 45}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, CalculateMetadata(tc.totalRecords, tc.page, tc.pageSize), tc.want)
+		})
+	}
+}
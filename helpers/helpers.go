@@ -5,9 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"math/rand"
+	"mime"
 	"net/http"
 	"net/url"
+	"runtime/debug"
 	"strconv"
 	"strings"
 	"sync"
@@ -37,24 +40,69 @@ func GenerateUniqueId(length int) string {
 	return string(generatedId)
 }
 
+// PanicError describes a panic recovered from a function run in the background.
+// Stack holds the stack trace of the goroutine at the time of the panic.
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+// Error returns the panic value formatted as an error message.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error, so errors.Is and errors.As can inspect it.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+var panicHandler = func(err *PanicError) {
+	slog.Error("background function panicked", "panic", err.Value, "stack", string(err.Stack))
+}
+
+// SetPanicHandler registers the function that receives every panic recovered by RunInBackground.
+// By default panics are logged through slog.Default. Passing nil disables reporting.
+func SetPanicHandler(handler func(err *PanicError)) {
+	panicHandler = handler
+}
+
+// reportPanic captures the stack of a recovered panic and passes it to the registered panic handler.
+// It must be called from the deferred function that recovered the panic.
+func reportPanic(recovered any) *PanicError {
+	err := &PanicError{Value: recovered, Stack: debug.Stack()}
+
+	if panicHandler != nil {
+		panicHandler(err)
+	}
+
+	return err
+}
+
 // RunInBackground runs the given function in a separate goroutine and adds it to the wait group.
 // The wait group is incremented before the goroutine starts and decremented after it finishes.
-// If the function panics, it is recovered and the panic message can be logged to a logger service.
-func RunInBackground(fn func(), wg *sync.WaitGroup) {
+// If the function panics, it is recovered and reported to the handler registered with SetPanicHandler.
+// The returned channel receives a *PanicError if the function panicked and is closed once it returns.
+func RunInBackground(fn func(), wg *sync.WaitGroup) <-chan error {
+	done := make(chan error, 1)
+
 	wg.Add(1)
 
 	go func() {
 		defer wg.Done()
+		defer close(done)
 
-		// defer func() {
-		// 	if err := recover(); err != nil {
-		// 		//write to logger service here - goroutine
-		// 		//app.logger.Error(fmt.Sprintf("%v", err))
-		// 	}
-		// }()
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- reportPanic(recovered)
+			}
+		}()
 
 		fn()
 	}()
+
+	return done
 }
 
 // ReadIDParam extracts and parses the "id" parameter from the given HTTP request.
@@ -70,10 +118,45 @@ func ReadIDParam(r *http.Request) (int64, error) {
 	return id, nil
 }
 
+var prettyJSON = true
+
+// SetPrettyJSON sets whether WriteJSON indents its output. Indented output is the default.
+// Services serving large responses can switch to compact output and let clients opt in
+// per request with ?pretty=true through WriteJSONRequest.
+func SetPrettyJSON(pretty bool) {
+	prettyJSON = pretty
+}
+
 // WriteJSON writes the provided data as a JSON response to the http.ResponseWriter.
 // It sets the provided status code, headers, and content type.
-func WriteJSON(w http.ResponseWriter, status int, data Envelope, headers http.Header) {
-	js, _ := json.MarshalIndent(data, "", "\t")
+// The content type defaults to application/json unless one is supplied in headers.
+// If the data cannot be encoded, nothing is written and the error is returned so a server error can be sent instead.
+func WriteJSON(w http.ResponseWriter, status int, data Envelope, headers http.Header) error {
+	return writeJSON(w, status, data, headers, prettyJSON)
+}
+
+// WriteJSONRequest writes the provided data as a JSON response like WriteJSON, indenting
+// the output when pretty printing is enabled or the request asks for it with ?pretty=true.
+func WriteJSONRequest(w http.ResponseWriter, r *http.Request, status int, data Envelope, headers http.Header) error {
+	pretty, _ := strconv.ParseBool(r.URL.Query().Get("pretty"))
+
+	return writeJSON(w, status, data, headers, prettyJSON || pretty)
+}
+
+// writeJSON encodes the data, indented with tabs if pretty is set, and writes the response.
+func writeJSON(w http.ResponseWriter, status int, data Envelope, headers http.Header, pretty bool) error {
+	var js []byte
+	var err error
+
+	if pretty {
+		js, err = json.MarshalIndent(data, "", "\t")
+	} else {
+		js, err = json.Marshal(data)
+	}
+
+	if err != nil {
+		return err
+	}
 
 	js = append(js, '\n')
 
@@ -83,21 +166,58 @@ func WriteJSON(w http.ResponseWriter, status int, data Envelope, headers http.He
 		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
+	if headers.Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
 	w.WriteHeader(status)
 	w.Write(js)
+
+	return nil
+}
+
+// ReadJSONOptions configures ReadJSONWithOptions. The zero value applies the same rules as ReadJSON.
+// MaxBytes limits the size of the request body and defaults to 1MB.
+// RequireContentType rejects requests whose Content-Type header is not application/json or an
+// application/*+json type, so they can be answered with errors.UnsupportedMediaTypeResponse.
+// AllowMultipleValues decodes the first JSON value of the body and ignores anything after it.
+type ReadJSONOptions struct {
+	MaxBytes            int64
+	AllowUnknownFields  bool
+	UseNumber           bool
+	RequireContentType  bool
+	AllowMultipleValues bool
 }
 
 // ReadJSON reads and decodes JSON data from the request body into the provided destination object.
 // It enforces a maximum request body size of 1MB and disallows unknown fields in the JSON.
-// If any errors occur during decoding, appropriate error messages are returned.
+// If any errors occur during decoding, a *JSONError describing the problem is returned.
 // The function returns nil if the decoding is successful.
 func ReadJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
-	maxBytes := 1_048_576 // 1MB max request body
-	r.Body = http.MaxBytesReader(w, r.Body, int64(maxBytes))
+	return ReadJSONWithOptions(w, r, dst, ReadJSONOptions{})
+}
+
+// ReadJSONWithOptions reads and decodes JSON data from the request body into the provided destination object,
+// applying the given options. It returns the same error messages as ReadJSON.
+func ReadJSONWithOptions(w http.ResponseWriter, r *http.Request, dst interface{}, opts ReadJSONOptions) error {
+	if opts.RequireContentType && !isJSONContentType(r.Header.Get("Content-Type")) {
+		return &JSONError{Kind: JSONContentType}
+	}
+
+	maxBytes := opts.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = 1_048_576 // 1MB max request body
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
 
 	dec := json.NewDecoder(r.Body)
-	dec.DisallowUnknownFields()
+
+	if !opts.AllowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	if opts.UseNumber {
+		dec.UseNumber()
+	}
 
 	err := dec.Decode(dst)
 	if err != nil {
@@ -108,26 +228,26 @@ func ReadJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
 
 		switch {
 		case errors.As(err, &syntaxError):
-			return fmt.Errorf("body contains badly-formed JSON (at character %d)", syntaxError.Offset)
+			return &JSONError{Kind: JSONSyntax, Offset: syntaxError.Offset, Err: err}
 
 		case errors.Is(err, io.ErrUnexpectedEOF):
-			return errors.New("body contains badly-formed JSON")
+			return &JSONError{Kind: JSONSyntax, Err: err}
 
 		case errors.As(err, &unmarshalTypeError):
-			if unmarshalTypeError.Field != "" {
-				return fmt.Errorf("body contains incorrect JSON type for field %q", unmarshalTypeError.Field)
-			}
-			return fmt.Errorf("body contains incorrect JSON type (at character %d)", unmarshalTypeError.Offset)
+			return &JSONError{Kind: JSONType, Field: unmarshalTypeError.Field, Offset: unmarshalTypeError.Offset, Err: err}
 
 		case errors.Is(err, io.EOF):
-			return errors.New("body must not be empty")
+			return &JSONError{Kind: JSONEmpty, Err: err}
 
 		case strings.HasPrefix(err.Error(), "json: unknown field "):
 			fieldName := strings.TrimPrefix(err.Error(), "json: unknown field ")
-			return fmt.Errorf("body contains unknown key %s", fieldName)
+			if unquoted, unquoteErr := strconv.Unquote(fieldName); unquoteErr == nil {
+				fieldName = unquoted
+			}
+			return &JSONError{Kind: JSONUnknownField, Field: fieldName, Err: err}
 
 		case errors.As(err, &maxBytesError):
-			return fmt.Errorf("body must not be larger than %d bytes", maxBytesError.Limit)
+			return &JSONError{Kind: JSONTooLarge, Limit: maxBytesError.Limit, Err: err}
 
 		case errors.As(err, &invalidUnmarshalError):
 			panic(err)
@@ -137,14 +257,29 @@ func ReadJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
 		}
 	}
 
+	if opts.AllowMultipleValues {
+		return nil
+	}
+
 	err = dec.Decode(&struct{}{})
 	if !errors.Is(err, io.EOF) {
-		return errors.New("body must only contain a single JSON value")
+		return &JSONError{Kind: JSONMultipleValues}
 	}
 
 	return nil
 }
 
+// isJSONContentType reports whether the Content-Type header value is application/json
+// or a structured syntax JSON type such as application/merge-patch+json.
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return mediaType == "application/json" || strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json")
+}
+
 // ReadString reads a string value from the given url.Values object based on the provided key.
 // If the value is empty, it returns the defaultValue.
 func ReadString(qs url.Values, key string, defaultValue string) string {
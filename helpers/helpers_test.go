@@ -12,6 +12,7 @@ import (
 	"net/url"
 	"reflect"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/julienschmidt/httprouter"
@@ -36,6 +37,38 @@ func TestGenerateUniqueId(t *testing.T) {
 	}
 }
 
+func TestRunInBackground(t *testing.T) {
+	tests := []struct {
+		name     string
+		fn       func()
+		panicked bool
+	}{
+		{name: "Function completes", fn: func() {}, panicked: false},
+		{name: "Function panics", fn: func() { panic("boom") }, panicked: true},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var reported *PanicError
+			defer SetPanicHandler(panicHandler)
+			SetPanicHandler(func(err *PanicError) { reported = err })
+
+			var wg sync.WaitGroup
+			done := RunInBackground(tc.fn, &wg)
+			err := <-done
+			wg.Wait()
+
+			assert.Equal(t, err != nil, tc.panicked)
+			assert.Equal(t, reported != nil, tc.panicked)
+
+			if tc.panicked {
+				assert.Equal(t, err.Error(), "panic: boom")
+				assert.Equal(t, len(reported.Stack) > 0, true)
+			}
+		})
+	}
+}
+
 func TestReadIDParam(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "/", nil)
 
@@ -93,6 +126,44 @@ func TestWriteJSON(t *testing.T) {
 	}
 }
 
+func TestWriteJSONFormatting(t *testing.T) {
+	tests := []struct {
+		name   string
+		pretty bool
+		target string
+		want   string
+	}{
+		{name: "Pretty by default", pretty: true, target: "/", want: "{\n\t\"data\": \"value\"\n}\n"},
+		{name: "Compact when disabled", pretty: false, target: "/", want: "{\"data\":\"value\"}\n"},
+		{name: "Pretty when requested", pretty: false, target: "/?pretty=true", want: "{\n\t\"data\": \"value\"\n}\n"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			SetPrettyJSON(tc.pretty)
+			defer SetPrettyJSON(true)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, tc.target, nil)
+
+			err := WriteJSONRequest(w, r, http.StatusOK, Envelope{"data": "value"}, nil)
+
+			assert.Equal(t, err, nil)
+			assert.Equal(t, w.Body.String(), tc.want)
+		})
+	}
+}
+
+func TestWriteJSONUnsupportedValue(t *testing.T) {
+	w := httptest.NewRecorder()
+
+	err := WriteJSON(w, http.StatusOK, Envelope{"data": make(chan int)}, nil)
+
+	assert.Equal(t, err != nil, true)
+	assert.Equal(t, w.Body.Len(), 0)
+	assert.Equal(t, w.Header().Get("Content-Type"), "")
+}
+
 func checkCustomHeader(t *testing.T, message string, resp *http.Response) {
 	if message == "success" {
 		headerValue := resp.Header.Get("X-Custom-Header")
@@ -357,3 +428,55 @@ func TestReadInt(t *testing.T) {
 		})
 	}
 }
+
+func TestReadJSONWithOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		opts        ReadJSONOptions
+		err         string
+	}{
+		{name: "Larger body limit", body: `{"data":"` + strings.Repeat("x", 2_000_000) + `"}`, opts: ReadJSONOptions{MaxBytes: 4_000_000}},
+		{name: "Smaller body limit", body: `{"data":"value"}`, opts: ReadJSONOptions{MaxBytes: 4}, err: "body must not be larger than 4 bytes"},
+		{name: "Unknown fields allowed", body: `{"data":"value","oddKey":"oddValue"}`, opts: ReadJSONOptions{AllowUnknownFields: true}},
+		{name: "Multiple values allowed", body: `{"data":"value"}{"data":"other"}`, opts: ReadJSONOptions{AllowMultipleValues: true}},
+		{name: "Multiple values rejected", body: `{"data":"value"}{"data":"other"}`, err: "body must only contain a single JSON value"},
+		{name: "JSON content type accepted", body: `{"data":"value"}`, contentType: "application/json; charset=utf-8", opts: ReadJSONOptions{RequireContentType: true}},
+		{name: "JSON suffix content type accepted", body: `{"data":"value"}`, contentType: "application/merge-patch+json", opts: ReadJSONOptions{RequireContentType: true}},
+		{name: "Missing content type rejected", body: `{"data":"value"}`, opts: ReadJSONOptions{RequireContentType: true}, err: "body must be sent with the application/json content type"},
+		{name: "Other content type rejected", body: `{"data":"value"}`, contentType: "text/plain", opts: ReadJSONOptions{RequireContentType: true}, err: "body must be sent with the application/json content type"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			if tc.contentType != "" {
+				r.Header.Set("Content-Type", tc.contentType)
+			}
+			var dst struct {
+				Data string `json:"data"`
+			}
+
+			err := ReadJSONWithOptions(w, r, &dst, tc.opts)
+
+			if tc.err != "" {
+				assert.Equal(t, err.Error(), tc.err)
+			} else {
+				assert.Equal(t, err, nil)
+			}
+		})
+	}
+}
+
+func TestReadJSONUseNumber(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"amount":12345678901234567890}`))
+	var dst map[string]interface{}
+
+	err := ReadJSONWithOptions(w, r, &dst, ReadJSONOptions{UseNumber: true})
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, dst["amount"], interface{}(json.Number("12345678901234567890")))
+}
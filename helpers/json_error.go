@@ -0,0 +1,77 @@
+package helpers
+
+import (
+	"fmt"
+)
+
+// JSONErrorKind identifies why a request body could not be decoded.
+type JSONErrorKind int
+
+const (
+	// JSONSyntax means the body is not well-formed JSON.
+	JSONSyntax JSONErrorKind = iota + 1
+	// JSONType means a JSON value does not match the type of the destination field.
+	JSONType
+	// JSONEmpty means the body is empty.
+	JSONEmpty
+	// JSONUnknownField means the body contains a key that does not match a destination field.
+	JSONUnknownField
+	// JSONTooLarge means the body exceeds the maximum size.
+	JSONTooLarge
+	// JSONMultipleValues means the body contains more than one JSON value.
+	JSONMultipleValues
+	// JSONContentType means the request was not sent with the JSON content type.
+	JSONContentType
+)
+
+// JSONError describes a request body that ReadJSON could not decode.
+// Field is set for JSONType and JSONUnknownField errors, Offset for JSONSyntax and JSONType errors
+// when the position is known, and Limit for JSONTooLarge errors.
+// Err holds the underlying decoding error, if any.
+type JSONError struct {
+	Kind   JSONErrorKind
+	Field  string
+	Offset int64
+	Limit  int64
+	Err    error
+}
+
+// Error returns a message describing the problem that is safe to show to clients.
+func (e *JSONError) Error() string {
+	switch e.Kind {
+	case JSONSyntax:
+		if e.Offset > 0 {
+			return fmt.Sprintf("body contains badly-formed JSON (at character %d)", e.Offset)
+		}
+		return "body contains badly-formed JSON"
+
+	case JSONType:
+		if e.Field != "" {
+			return fmt.Sprintf("body contains incorrect JSON type for field %q", e.Field)
+		}
+		return fmt.Sprintf("body contains incorrect JSON type (at character %d)", e.Offset)
+
+	case JSONEmpty:
+		return "body must not be empty"
+
+	case JSONUnknownField:
+		return fmt.Sprintf("body contains unknown key %q", e.Field)
+
+	case JSONTooLarge:
+		return fmt.Sprintf("body must not be larger than %d bytes", e.Limit)
+
+	case JSONMultipleValues:
+		return "body must only contain a single JSON value"
+
+	case JSONContentType:
+		return "body must be sent with the application/json content type"
+
+	default:
+		return "body could not be decoded"
+	}
+}
+
+// Unwrap returns the underlying decoding error.
+func (e *JSONError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,47 @@
+package helpers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/windevkay/flhoutils/assert"
+)
+
+func TestReadJSONErrorKinds(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		kind   JSONErrorKind
+		field  string
+		offset int64
+	}{
+		{name: "Syntax error", body: `{"data": value}`, kind: JSONSyntax, offset: 10},
+		{name: "Unexpected end of body", body: `{"data": "value"`, kind: JSONSyntax},
+		{name: "Incorrect type", body: `{"data": 1}`, kind: JSONType, field: "data", offset: 10},
+		{name: "Empty body", body: ``, kind: JSONEmpty},
+		{name: "Unknown field", body: `{"oddKey": 1}`, kind: JSONUnknownField, field: "oddKey"},
+		{name: "Body too large", body: `{"data": "` + strings.Repeat("x", 1_048_576) + `"}`, kind: JSONTooLarge},
+		{name: "Multiple values", body: `{} {}`, kind: JSONMultipleValues},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tc.body))
+			var dst struct {
+				Data string `json:"data"`
+			}
+
+			err := ReadJSON(w, r, &dst)
+
+			var jsonError *JSONError
+			assert.Equal(t, errors.As(err, &jsonError), true)
+			assert.Equal(t, jsonError.Kind, tc.kind)
+			assert.Equal(t, jsonError.Field, tc.field)
+			assert.Equal(t, jsonError.Offset, tc.offset)
+		})
+	}
+}
@@ -0,0 +1,215 @@
+package helpers
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ErrNotAcceptable is returned by WriteResponse when no registered encoder matches the Accept header.
+var ErrNotAcceptable = errors.New("no acceptable media type")
+
+// Encoder encodes response bodies in a single media type.
+type Encoder interface {
+	Encode(w io.Writer, v any) error
+}
+
+// EncoderFunc adapts an ordinary function to the Encoder interface.
+type EncoderFunc func(w io.Writer, v any) error
+
+// Encode calls f(w, v).
+func (f EncoderFunc) Encode(w io.Writer, v any) error {
+	return f(w, v)
+}
+
+// registeredEncoder pairs an encoder with the media type it produces.
+type registeredEncoder struct {
+	mediaType string
+	encoder   Encoder
+}
+
+// encoders lists the available encoders in order of server preference.
+// JSON comes first, so it is used when the client accepts anything.
+var encoders = []registeredEncoder{
+	{mediaType: "application/json"},
+	{mediaType: "application/xml", encoder: EncoderFunc(encodeXML)},
+	{mediaType: "text/xml", encoder: EncoderFunc(encodeXML)},
+	{mediaType: "application/msgpack", encoder: EncoderFunc(encodeMessagePack)},
+	{mediaType: "application/x-msgpack", encoder: EncoderFunc(encodeMessagePack)},
+	{mediaType: "application/vnd.msgpack", encoder: EncoderFunc(encodeMessagePack)},
+	{mediaType: "application/cbor", encoder: EncoderFunc(encodeCBOR)},
+}
+
+// RegisterEncoder adds an encoder for the media type, or replaces the encoder already registered for it.
+// New media types have the lowest server preference. It is intended to be called during startup.
+func RegisterEncoder(mediaType string, encoder Encoder) {
+	mediaType = strings.ToLower(mediaType)
+
+	for i := range encoders {
+		if encoders[i].mediaType == mediaType {
+			encoders[i].encoder = encoder
+			return
+		}
+	}
+
+	encoders = append(encoders, registeredEncoder{mediaType: mediaType, encoder: encoder})
+}
+
+// SupportedMediaTypes returns the media types of the registered encoders in order of server preference.
+func SupportedMediaTypes() []string {
+	mediaTypes := make([]string, 0, len(encoders))
+
+	for _, e := range encoders {
+		mediaTypes = append(mediaTypes, e.mediaType)
+	}
+
+	return mediaTypes
+}
+
+// NegotiateMediaType returns the registered media type that best matches the Accept header of the request.
+// The client's quality values take precedence, followed by the server preference.
+// A request without an Accept header is given JSON. It returns false if nothing is acceptable.
+func NegotiateMediaType(r *http.Request) (string, bool) {
+	accept := r.Header.Values("Accept")
+	if len(accept) == 0 {
+		return encoders[0].mediaType, true
+	}
+
+	ranges := parseAccept(strings.Join(accept, ","))
+
+	best := ""
+	bestQuality := 0.0
+
+	for _, e := range encoders {
+		quality := acceptQuality(ranges, e.mediaType)
+		if quality > bestQuality {
+			best = e.mediaType
+			bestQuality = quality
+		}
+	}
+
+	return best, best != ""
+}
+
+// WriteResponse writes the data encoded in the media type negotiated from the Accept header.
+// It sets the provided status code, headers and content type, and adds Accept to the Vary header.
+// If no registered encoder is acceptable, nothing is written and ErrNotAcceptable is returned,
+// so errors.NotAcceptableResponse can be sent instead.
+func WriteResponse(w http.ResponseWriter, r *http.Request, status int, data Envelope, headers http.Header) error {
+	mediaType, ok := NegotiateMediaType(r)
+	if !ok {
+		return ErrNotAcceptable
+	}
+
+	w.Header().Add("Vary", "Accept")
+
+	return WriteEncoded(w, r, mediaType, status, data, headers)
+}
+
+// WriteEncoded writes the data encoded by the encoder registered for the media type.
+// JSON is written by WriteJSONRequest. The content type defaults to the media type unless one is supplied in headers.
+// If the media type has no encoder or the data cannot be encoded, nothing is written and an error is returned.
+func WriteEncoded(w http.ResponseWriter, r *http.Request, mediaType string, status int, data Envelope, headers http.Header) error {
+	var encoder Encoder
+
+	for _, e := range encoders {
+		if e.mediaType == mediaType {
+			encoder = e.encoder
+			break
+		}
+	}
+
+	if encoder == nil {
+		if mediaType != "application/json" {
+			return ErrNotAcceptable
+		}
+
+		return WriteJSONRequest(w, r, status, data, headers)
+	}
+
+	var buf bytes.Buffer
+
+	err := encoder.Encode(&buf, data)
+	if err != nil {
+		return err
+	}
+
+	for key, values := range headers {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+
+	if headers.Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", mediaType)
+	}
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+
+	return nil
+}
+
+// acceptRange is a single media range of an Accept header with its quality value.
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+// parseAccept parses the media ranges of an Accept header, skipping malformed ranges.
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params["q"]; ok {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+		}
+
+		ranges = append(ranges, acceptRange{mediaType: mediaType, quality: quality})
+	}
+
+	return ranges
+}
+
+// acceptQuality returns the quality value the client gives to the media type,
+// taken from the most specific range that matches it. It returns 0 if no range matches.
+func acceptQuality(ranges []acceptRange, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+
+	quality := 0.0
+	specificity := -1
+
+	for _, ar := range ranges {
+		var s int
+
+		switch {
+		case ar.mediaType == mediaType:
+			s = 2
+		case ar.mediaType == mainType+"/*":
+			s = 1
+		case ar.mediaType == "*/*":
+			s = 0
+		default:
+			continue
+		}
+
+		if s > specificity {
+			specificity = s
+			quality = ar.quality
+		}
+	}
+
+	return quality
+}
@@ -0,0 +1,90 @@
+package helpers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/windevkay/flhoutils/assert"
+)
+
+func TestNegotiateMediaType(t *testing.T) {
+	tests := []struct {
+		name   string
+		accept string
+		want   string
+		ok     bool
+	}{
+		{name: "No Accept header", accept: "", want: "application/json", ok: true},
+		{name: "Anything", accept: "*/*", want: "application/json", ok: true},
+		{name: "XML", accept: "application/xml", want: "application/xml", ok: true},
+		{name: "Quality values", accept: "application/json;q=0.5, application/cbor", want: "application/cbor", ok: true},
+		{name: "Specific range overrides wildcard", accept: "application/*;q=0.9, application/json;q=0.1", want: "application/xml", ok: true},
+		{name: "Excluded type", accept: "application/json;q=0, */*;q=0.1", want: "application/xml", ok: true},
+		{name: "Nothing acceptable", accept: "image/png", want: "", ok: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.accept != "" {
+				r.Header.Set("Accept", tc.accept)
+			}
+
+			mediaType, ok := NegotiateMediaType(r)
+
+			assert.Equal(t, mediaType, tc.want)
+			assert.Equal(t, ok, tc.ok)
+		})
+	}
+}
+
+func TestWriteResponse(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		err         error
+	}{
+		{name: "JSON", accept: "application/json", contentType: "application/json", err: nil},
+		{name: "MessagePack", accept: "application/msgpack", contentType: "application/msgpack", err: nil},
+		{name: "Not acceptable", accept: "text/html", contentType: "", err: ErrNotAcceptable},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept", tc.accept)
+
+			err := WriteResponse(w, r, http.StatusCreated, Envelope{"id": 1}, nil)
+
+			assert.Equal(t, errors.Is(err, tc.err), true)
+			assert.Equal(t, w.Header().Get("Content-Type"), tc.contentType)
+			if tc.err == nil {
+				assert.Equal(t, w.Code, http.StatusCreated)
+				assert.Equal(t, w.Header().Get("Vary"), "Accept")
+			}
+		})
+	}
+}
+
+func TestRegisterEncoder(t *testing.T) {
+	defer func(registered []registeredEncoder) { encoders = registered }(append([]registeredEncoder(nil), encoders...))
+
+	RegisterEncoder("text/plain", EncoderFunc(func(w io.Writer, v any) error {
+		_, err := io.WriteString(w, "plain")
+		return err
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept", "text/plain")
+
+	err := WriteResponse(w, r, http.StatusOK, Envelope{}, nil)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, w.Body.String(), "plain")
+}
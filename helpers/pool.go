@@ -0,0 +1,169 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// Backpressure determines what Pool.Submit does when the queue of a pool is full.
+type Backpressure int
+
+const (
+	// BackpressureBlock waits for room in the queue, the submit context to be done or the pool to shut down.
+	BackpressureBlock Backpressure = iota
+	// BackpressureDrop discards the task and counts it in Pool.Dropped.
+	BackpressureDrop
+	// BackpressureError rejects the task with ErrPoolFull.
+	BackpressureError
+)
+
+var (
+	// ErrPoolClosed is returned when a task is submitted to a pool that is shutting down.
+	ErrPoolClosed = errors.New("pool is shut down")
+	// ErrPoolFull is returned when the queue is full and the pool uses BackpressureError.
+	ErrPoolFull = errors.New("pool queue is full")
+)
+
+// PoolOptions configures a Pool.
+// Workers defaults to 1 and QueueSize to 0, which hands each task directly to an idle worker.
+type PoolOptions struct {
+	Workers      int
+	QueueSize    int
+	Backpressure Backpressure
+}
+
+// Pool runs background tasks on a fixed number of workers fed from a bounded queue.
+// It replaces RunInBackground where an unbounded number of goroutines would be started.
+type Pool struct {
+	backpressure Backpressure
+	tasks        chan func(ctx context.Context)
+	ctx          context.Context
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
+	mu           sync.RWMutex
+	closed       bool
+	quit         chan struct{}
+	quitOnce     sync.Once
+	dropped      atomic.Int64
+}
+
+// NewPool creates a Pool and starts its workers.
+func NewPool(opts PoolOptions) *Pool {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	queueSize := opts.QueueSize
+	if queueSize < 0 {
+		queueSize = 0
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	p := &Pool{
+		backpressure: opts.Backpressure,
+		tasks:        make(chan func(ctx context.Context), queueSize),
+		ctx:          ctx,
+		cancel:       cancel,
+		quit:         make(chan struct{}),
+	}
+
+	p.wg.Add(workers)
+	for range workers {
+		go p.work()
+	}
+
+	return p
+}
+
+// work runs queued tasks until the queue is closed and drained.
+func (p *Pool) work() {
+	defer p.wg.Done()
+
+	for task := range p.tasks {
+		p.run(task)
+	}
+}
+
+// run executes a single task, reporting any panic to the handler registered with SetPanicHandler.
+func (p *Pool) run(task func(ctx context.Context)) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			reportPanic(recovered)
+		}
+	}()
+
+	task(p.ctx)
+}
+
+// Submit queues the task for execution. The context passed to the task is cancelled
+// if Shutdown gives up waiting for in-flight work.
+// When the queue is full, Submit follows the pool's Backpressure policy.
+func (p *Pool) Submit(ctx context.Context, task func(ctx context.Context)) error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.closed {
+		return ErrPoolClosed
+	}
+
+	select {
+	case p.tasks <- task:
+		return nil
+	default:
+	}
+
+	switch p.backpressure {
+	case BackpressureDrop:
+		p.dropped.Add(1)
+		return nil
+	case BackpressureError:
+		return ErrPoolFull
+	}
+
+	select {
+	case p.tasks <- task:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.quit:
+		return ErrPoolClosed
+	}
+}
+
+// Dropped returns the number of tasks discarded by the BackpressureDrop policy.
+func (p *Pool) Dropped() int64 {
+	return p.dropped.Load()
+}
+
+// Shutdown stops the pool from accepting tasks and waits for queued and in-flight tasks to finish.
+// If the context is done first, the context of running tasks is cancelled and the context error is returned.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.quitOnce.Do(func() { close(p.quit) })
+
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.tasks)
+	}
+	p.mu.Unlock()
+
+	drained := make(chan struct{})
+
+	go func() {
+		p.wg.Wait()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		p.cancel()
+		return nil
+	case <-ctx.Done():
+		p.cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,103 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/windevkay/flhoutils/assert"
+)
+
+func TestPoolRunsAndDrainsTasks(t *testing.T) {
+	p := NewPool(PoolOptions{Workers: 3, QueueSize: 10})
+
+	var count atomic.Int64
+	for range 10 {
+		err := p.Submit(context.Background(), func(ctx context.Context) {
+			time.Sleep(time.Millisecond)
+			count.Add(1)
+		})
+		assert.Equal(t, err, nil)
+	}
+
+	err := p.Shutdown(context.Background())
+	assert.Equal(t, err, nil)
+	assert.Equal(t, count.Load(), int64(10))
+
+	err = p.Submit(context.Background(), func(ctx context.Context) {})
+	assert.Equal(t, err, ErrPoolClosed)
+}
+
+func TestPoolBackpressure(t *testing.T) {
+	tests := []struct {
+		name         string
+		backpressure Backpressure
+		want         error
+		dropped      int64
+	}{
+		{name: "Drop discards tasks", backpressure: BackpressureDrop, want: nil, dropped: 1},
+		{name: "Error rejects tasks", backpressure: BackpressureError, want: ErrPoolFull, dropped: 0},
+		{name: "Block waits for the submit context", backpressure: BackpressureBlock, want: context.DeadlineExceeded, dropped: 0},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			p := NewPool(PoolOptions{Workers: 1, QueueSize: 1, Backpressure: tc.backpressure})
+
+			release := make(chan struct{})
+			started := make(chan struct{})
+			p.Submit(context.Background(), func(ctx context.Context) {
+				close(started)
+				<-release
+			})
+			<-started
+			p.Submit(context.Background(), func(ctx context.Context) {})
+
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+
+			err := p.Submit(ctx, func(ctx context.Context) {})
+			assert.Equal(t, err, tc.want)
+			assert.Equal(t, p.Dropped(), tc.dropped)
+
+			close(release)
+			assert.Equal(t, p.Shutdown(context.Background()), nil)
+		})
+	}
+}
+
+func TestPoolShutdownTimeout(t *testing.T) {
+	p := NewPool(PoolOptions{Workers: 1})
+
+	cancelled := make(chan struct{})
+	p.Submit(context.Background(), func(ctx context.Context) {
+		<-ctx.Done()
+		close(cancelled)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err := p.Shutdown(ctx)
+	assert.Equal(t, errors.Is(err, context.DeadlineExceeded), true)
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Error("Expected the task context to be cancelled")
+	}
+}
+
+func TestPoolRecoversPanics(t *testing.T) {
+	var reported atomic.Bool
+	defer SetPanicHandler(panicHandler)
+	SetPanicHandler(func(err *PanicError) { reported.Store(true) })
+
+	p := NewPool(PoolOptions{Workers: 1})
+	p.Submit(context.Background(), func(ctx context.Context) { panic("boom") })
+
+	assert.Equal(t, p.Shutdown(context.Background()), nil)
+	assert.Equal(t, reported.Load(), true)
+}
@@ -0,0 +1,171 @@
+package helpers
+
+import (
+	"encoding"
+	"fmt"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/windevkay/flhoutils/validator"
+)
+
+var (
+	durationType        = reflect.TypeOf(time.Duration(0))
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// ReadQuery fills the fields of dst from the query string using their `query` struct tags.
+// The tag holds the parameter name and an optional default used when the parameter is empty:
+//
+//	Page   int           `query:"page,default=1"`
+//	Sort   string        `query:"sort,default=-created_at"`
+//	Genres []string      `query:"genres"`
+//	Since  *time.Time    `query:"since"`
+//	Within time.Duration `query:"within,default=24h"`
+//
+// Strings, integers, booleans, floats, time.Time (RFC 3339), time.Duration, types implementing
+// encoding.TextUnmarshaler, pointers to these and slices of them (comma-separated or repeated) are supported.
+// Values that cannot be parsed are reported to the validator under the parameter name and the field is set
+// to its default, or left unchanged if it has none.
+// ReadQuery panics if dst is not a pointer to a struct, a field type is unsupported, or a default is invalid.
+func ReadQuery(qs url.Values, dst any, v *validator.Validator) {
+	rv := reflect.ValueOf(dst)
+
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		panic(fmt.Sprintf("helpers: ReadQuery requires a pointer to a struct, got %T", dst))
+	}
+
+	rv = rv.Elem()
+	rt := rv.Type()
+
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+
+		tag := field.Tag.Get("query")
+		if !field.IsExported() || tag == "" || tag == "-" {
+			continue
+		}
+
+		key, options, _ := strings.Cut(tag, ",")
+		defaultValue, hasDefault := strings.CutPrefix(options, "default=")
+
+		values := qs[key]
+		if len(values) > 0 && !(len(values) == 1 && values[0] == "") {
+			message := setQueryValue(rv.Field(i), values)
+			if message == "" {
+				continue
+			}
+			v.AddError(key, message)
+		}
+
+		if hasDefault {
+			message := setQueryValue(rv.Field(i), []string{defaultValue})
+			if message != "" {
+				panic(fmt.Sprintf("helpers: invalid default %q for query parameter %q", defaultValue, key))
+			}
+		}
+	}
+}
+
+// setQueryValue parses the raw values into the field.
+// It returns the message to report when the values cannot be parsed.
+func setQueryValue(field reflect.Value, values []string) string {
+	if field.Kind() == reflect.Slice && !field.Type().Implements(textUnmarshalerType) {
+		var items []string
+		for _, value := range values {
+			items = append(items, strings.Split(value, ",")...)
+		}
+
+		slice := reflect.MakeSlice(field.Type(), len(items), len(items))
+		for i, item := range items {
+			message := setQueryValue(slice.Index(i), []string{item})
+			if message != "" {
+				return message
+			}
+		}
+
+		field.Set(slice)
+		return ""
+	}
+
+	if field.Kind() == reflect.Pointer {
+		elem := reflect.New(field.Type().Elem())
+
+		message := setQueryValue(elem.Elem(), values)
+		if message != "" {
+			return message
+		}
+
+		field.Set(elem)
+		return ""
+	}
+
+	raw := values[0]
+
+	switch {
+	case field.Type() == durationType:
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return "must be a valid duration"
+		}
+		field.SetInt(int64(d))
+		return ""
+
+	case field.Type() == timeType:
+		t, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return "must be a valid RFC 3339 timestamp"
+		}
+		field.Set(reflect.ValueOf(t))
+		return ""
+
+	case reflect.PointerTo(field.Type()).Implements(textUnmarshalerType):
+		err := field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+		if err != nil {
+			return "must be a valid value"
+		}
+		return ""
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return "must be a boolean value"
+		}
+		field.SetBool(b)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return "must be an integer value"
+		}
+		field.SetInt(i)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return "must be a non-negative integer value"
+		}
+		field.SetUint(u)
+
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return "must be a number"
+		}
+		field.SetFloat(f)
+
+	default:
+		panic(fmt.Sprintf("helpers: ReadQuery does not support fields of type %s", field.Type()))
+	}
+
+	return ""
+}
@@ -0,0 +1,84 @@
+package helpers
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/windevkay/flhoutils/assert"
+	"github.com/windevkay/flhoutils/validator"
+)
+
+type listQuery struct {
+	Title    string        `query:"title"`
+	Page     int           `query:"page,default=1"`
+	Ratio    float64       `query:"ratio"`
+	Archived bool          `query:"archived,default=false"`
+	Genres   []string      `query:"genres,default=all,drama"`
+	IDs      []int64       `query:"ids"`
+	Since    *time.Time    `query:"since"`
+	Within   time.Duration `query:"within,default=24h"`
+	Limit    *uint         `query:"limit"`
+	Ignored  string
+}
+
+func TestReadQuery(t *testing.T) {
+	qs := url.Values{}
+	qs.Add("title", "up")
+	qs.Add("ratio", "0.5")
+	qs.Add("ids", "1,2")
+	qs.Add("ids", "3")
+	qs.Add("since", "2024-01-02T03:04:05Z")
+	qs.Add("limit", "10")
+	qs.Add("Ignored", "value")
+
+	var dst listQuery
+	v := validator.New()
+	ReadQuery(qs, &dst, v)
+
+	assert.Equal(t, v.Valid(), true)
+	assert.Equal(t, dst.Title, "up")
+	assert.Equal(t, dst.Page, 1)
+	assert.Equal(t, dst.Ratio, 0.5)
+	assert.Equal(t, dst.Archived, false)
+	assert.Equal(t, len(dst.Genres), 2)
+	assert.Equal(t, dst.Genres[1], "drama")
+	assert.Equal(t, len(dst.IDs), 3)
+	assert.Equal(t, dst.IDs[2], int64(3))
+	assert.Equal(t, dst.Since.Equal(time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)), true)
+	assert.Equal(t, dst.Within, 24*time.Hour)
+	assert.Equal(t, *dst.Limit, uint(10))
+	assert.Equal(t, dst.Ignored, "")
+}
+
+func TestReadQueryErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     string
+		value   string
+		message string
+	}{
+		{name: "Invalid integer", key: "page", value: "x", message: "must be an integer value"},
+		{name: "Invalid boolean", key: "archived", value: "maybe", message: "must be a boolean value"},
+		{name: "Invalid float", key: "ratio", value: "half", message: "must be a number"},
+		{name: "Invalid slice element", key: "ids", value: "1,x", message: "must be an integer value"},
+		{name: "Invalid time", key: "since", value: "yesterday", message: "must be a valid RFC 3339 timestamp"},
+		{name: "Invalid duration", key: "within", value: "a day", message: "must be a valid duration"},
+		{name: "Negative unsigned integer", key: "limit", value: "-1", message: "must be a non-negative integer value"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			qs := url.Values{}
+			qs.Add(tc.key, tc.value)
+
+			var dst listQuery
+			v := validator.New()
+			ReadQuery(qs, &dst, v)
+
+			assert.Equal(t, v.Errors[tc.key], tc.message)
+			assert.Equal(t, dst.Page, 1)
+			assert.Equal(t, dst.Within, 24*time.Hour)
+		})
+	}
+}
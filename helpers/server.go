@@ -0,0 +1,124 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ServeOptions configures Serve and ServeContext.
+// ShutdownTimeout defaults to 30 seconds and bounds the whole shutdown, including background work.
+// WaitGroup is the wait group passed to RunInBackground and Pool is a worker pool to drain, both optional.
+type ServeOptions struct {
+	ShutdownTimeout time.Duration
+	WaitGroup       *sync.WaitGroup
+	Pool            *Pool
+	Logger          *slog.Logger
+}
+
+// Serve runs the server until it receives SIGINT or SIGTERM and then shuts it down gracefully.
+// See ServeContext for the shutdown sequence.
+func Serve(srv *http.Server, opts ServeOptions) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	return ServeContext(ctx, srv, opts)
+}
+
+// ServeContext runs the server until the context is done and then shuts it down gracefully.
+// In-flight requests are completed first, after which background tasks in the wait group and pool are drained.
+// It returns the error that stopped the server from listening, or every error encountered during shutdown.
+func ServeContext(ctx context.Context, srv *http.Server, opts ServeOptions) error {
+	timeout := opts.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	serveErr := make(chan error, 1)
+
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	logInfo(opts.Logger, "starting server", "addr", srv.Addr)
+
+	select {
+	case err := <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			return nil
+		}
+		return err
+	case <-ctx.Done():
+	}
+
+	logInfo(opts.Logger, "shutting down server", "addr", srv.Addr)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var errs []error
+
+	err := srv.Shutdown(shutdownCtx)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("shutting down server: %w", err))
+	}
+
+	if opts.WaitGroup != nil {
+		logInfo(opts.Logger, "completing background tasks", "addr", srv.Addr)
+
+		err = waitContext(shutdownCtx, opts.WaitGroup)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("completing background tasks: %w", err))
+		}
+	}
+
+	if opts.Pool != nil {
+		err = opts.Pool.Shutdown(shutdownCtx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("shutting down pool: %w", err))
+		}
+	}
+
+	err = <-serveErr
+	if !errors.Is(err, http.ErrServerClosed) {
+		errs = append(errs, err)
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	logInfo(opts.Logger, "stopped server", "addr", srv.Addr)
+
+	return nil
+}
+
+// waitContext waits for the wait group, giving up when the context is done.
+func waitContext(ctx context.Context, wg *sync.WaitGroup) error {
+	done := make(chan struct{})
+
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// logInfo writes to the logger when one is configured.
+func logInfo(logger *slog.Logger, msg string, args ...any) {
+	if logger != nil {
+		logger.Info(msg, args...)
+	}
+}
@@ -0,0 +1,56 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/windevkay/flhoutils/assert"
+)
+
+func TestServeContext(t *testing.T) {
+	tests := []struct {
+		name     string
+		taskTime time.Duration
+		timeout  time.Duration
+		finished bool
+	}{
+		{name: "Drains background tasks", taskTime: 20 * time.Millisecond, timeout: time.Second, finished: true},
+		{name: "Reports timed out background tasks", taskTime: time.Second, timeout: 20 * time.Millisecond, finished: false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := &http.Server{Addr: "127.0.0.1:0", Handler: http.NotFoundHandler()}
+
+			var wg sync.WaitGroup
+			var finished atomic.Bool
+			RunInBackground(func() {
+				time.Sleep(tc.taskTime)
+				finished.Store(true)
+			}, &wg)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(10*time.Millisecond, cancel)
+
+			err := ServeContext(ctx, srv, ServeOptions{ShutdownTimeout: tc.timeout, WaitGroup: &wg})
+
+			assert.Equal(t, finished.Load(), tc.finished)
+			assert.Equal(t, err == nil, tc.finished)
+			if !tc.finished {
+				assert.Equal(t, errors.Is(err, context.DeadlineExceeded), true)
+			}
+		})
+	}
+}
+
+func TestServeContextListenError(t *testing.T) {
+	srv := &http.Server{Addr: "127.0.0.1:-1"}
+
+	err := ServeContext(context.Background(), srv, ServeOptions{})
+	assert.Equal(t, err != nil, true)
+}
@@ -0,0 +1,168 @@
+package helpers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is a single server-sent event. Data is encoded as JSON on the data line;
+// ID, Event and Retry are only written when set.
+type Event struct {
+	ID    string
+	Event string
+	Data  Envelope
+	Retry time.Duration
+}
+
+// SSEWriter writes server-sent events (text/event-stream) to a client.
+type SSEWriter struct {
+	w           http.ResponseWriter
+	rc          *http.ResponseController
+	ctx         context.Context
+	lastEventID string
+}
+
+var sseFieldSanitizer = strings.NewReplacer("\r", "", "\n", "")
+
+// NewSSEWriter starts an event stream by writing the event stream headers and a 200 status.
+// It returns http.ErrNotSupported, without changing the response, if the response writer does not
+// support flushing, so an error response can still be sent.
+func NewSSEWriter(w http.ResponseWriter, r *http.Request) (*SSEWriter, error) {
+	if !canFlush(w) {
+		return nil, http.ErrNotSupported
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+
+	s := &SSEWriter{
+		w:           w,
+		rc:          http.NewResponseController(w),
+		ctx:         r.Context(),
+		lastEventID: r.Header.Get("Last-Event-ID"),
+	}
+
+	w.WriteHeader(http.StatusOK)
+
+	err := s.rc.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// LastEventID returns the ID of the last event the client received before reconnecting,
+// taken from the Last-Event-ID request header. It is empty on the first connection.
+func (s *SSEWriter) LastEventID() string {
+	return s.lastEventID
+}
+
+// Send writes the event and flushes it to the client.
+func (s *SSEWriter) Send(e Event) error {
+	var buf bytes.Buffer
+
+	if e.ID != "" {
+		buf.WriteString("id: " + sseFieldSanitizer.Replace(e.ID) + "\n")
+	}
+
+	if e.Event != "" {
+		buf.WriteString("event: " + sseFieldSanitizer.Replace(e.Event) + "\n")
+	}
+
+	if e.Retry > 0 {
+		buf.WriteString("retry: " + strconv.FormatInt(e.Retry.Milliseconds(), 10) + "\n")
+	}
+
+	js, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+
+	buf.WriteString("data: ")
+	buf.Write(js)
+	buf.WriteString("\n\n")
+
+	return s.write(buf.Bytes())
+}
+
+// Heartbeat writes a comment line that keeps idle connections and proxies from timing out.
+func (s *SSEWriter) Heartbeat() error {
+	return s.write([]byte(": heartbeat\n\n"))
+}
+
+// Run sends the events received from the channel, writing a heartbeat whenever no event
+// has been sent for the heartbeat interval. A zero interval disables heartbeats.
+// It returns nil when the channel is closed and the context error when the client disconnects.
+func (s *SSEWriter) Run(events <-chan Event, heartbeat time.Duration) error {
+	var ticker *time.Ticker
+	var ticks <-chan time.Time
+
+	if heartbeat > 0 {
+		ticker = time.NewTicker(heartbeat)
+		defer ticker.Stop()
+		ticks = ticker.C
+	}
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return s.ctx.Err()
+
+		case e, ok := <-events:
+			if !ok {
+				return nil
+			}
+
+			err := s.Send(e)
+			if err != nil {
+				return err
+			}
+
+			if ticker != nil {
+				ticker.Reset(heartbeat)
+			}
+
+		case <-ticks:
+			err := s.Heartbeat()
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// write sends the frame and flushes it, unless the client has disconnected.
+func (s *SSEWriter) write(frame []byte) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+
+	_, err := s.w.Write(frame)
+	if err != nil {
+		return err
+	}
+
+	return s.rc.Flush()
+}
+
+// canFlush reports whether the response writer, or a writer it wraps, supports flushing.
+// It follows Unwrap methods the same way as http.ResponseController.
+func canFlush(w http.ResponseWriter) bool {
+	for {
+		switch t := w.(type) {
+		case interface{ FlushError() error }, http.Flusher:
+			return true
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = t.Unwrap()
+		default:
+			return false
+		}
+	}
+}
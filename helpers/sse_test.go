@@ -0,0 +1,128 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/windevkay/flhoutils/assert"
+)
+
+func TestSSEWriter(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/events", nil)
+	r.Header.Set("Last-Event-ID", "41")
+
+	s, err := NewSSEWriter(w, r)
+	if err != nil {
+		t.Fatalf("Failed to start event stream: %v", err)
+	}
+
+	assert.Equal(t, s.LastEventID(), "41")
+	assert.Equal(t, w.Header().Get("Content-Type"), "text/event-stream")
+	assert.Equal(t, w.Header().Get("Cache-Control"), "no-cache")
+
+	events := make(chan Event, 2)
+	events <- Event{ID: "42", Event: "movie\ncreated", Data: Envelope{"id": 42}, Retry: 5 * time.Second}
+	events <- Event{Data: Envelope{"id": 43}}
+	close(events)
+
+	err = s.Run(events, 0)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, w.Body.String(), "id: 42\nevent: moviecreated\nretry: 5000\ndata: {\"id\":42}\n\ndata: {\"id\":43}\n\n")
+}
+
+func TestSSEWriterHeartbeatAndDisconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/events", nil).WithContext(ctx)
+
+	s, err := NewSSEWriter(w, r)
+	if err != nil {
+		t.Fatalf("Failed to start event stream: %v", err)
+	}
+
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	err = s.Run(make(chan Event), 10*time.Millisecond)
+
+	assert.Equal(t, errors.Is(err, context.Canceled), true)
+	assert.Equal(t, strings.HasPrefix(w.Body.String(), ": heartbeat\n\n"), true)
+}
+
+func TestSSEWriterHeartbeatWhileSending(t *testing.T) {
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/events", nil)
+
+	s, err := NewSSEWriter(w, r)
+	if err != nil {
+		t.Fatalf("Failed to start event stream: %v", err)
+	}
+
+	events := make(chan Event)
+
+	go func() {
+		for i := range 20 {
+			events <- Event{Data: Envelope{"id": i}}
+			time.Sleep(10 * time.Millisecond)
+		}
+		close(events)
+	}()
+
+	err = s.Run(events, 100*time.Millisecond)
+
+	assert.Equal(t, err, nil)
+	assert.Equal(t, strings.Count(w.Body.String(), ": heartbeat"), 0)
+	assert.Equal(t, strings.Count(w.Body.String(), "data: "), 20)
+}
+
+// unflushableWriter is a response writer that does not support flushing.
+type unflushableWriter struct {
+	http.ResponseWriter
+}
+
+// unwrappingWriter wraps a response writer the way middleware does.
+type unwrappingWriter struct {
+	http.ResponseWriter
+}
+
+func (u unwrappingWriter) Unwrap() http.ResponseWriter {
+	return u.ResponseWriter
+}
+
+func TestNewSSEWriterFlushSupport(t *testing.T) {
+	tests := []struct {
+		name        string
+		wrap        func(w http.ResponseWriter) http.ResponseWriter
+		supported   bool
+		contentType string
+		status      int
+	}{
+		{name: "Flushing writer", wrap: func(w http.ResponseWriter) http.ResponseWriter { return w }, supported: true, contentType: "text/event-stream", status: http.StatusOK},
+		{name: "Wrapped flushing writer", wrap: func(w http.ResponseWriter) http.ResponseWriter { return unwrappingWriter{w} }, supported: true, contentType: "text/event-stream", status: http.StatusOK},
+		{name: "Writer without flushing", wrap: func(w http.ResponseWriter) http.ResponseWriter { return unflushableWriter{w} }, supported: false, status: http.StatusTeapot},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/events", nil)
+
+			_, err := NewSSEWriter(tc.wrap(w), r)
+
+			assert.Equal(t, err == nil, tc.supported)
+			if !tc.supported {
+				assert.Equal(t, errors.Is(err, http.ErrNotSupported), true)
+				w.WriteHeader(http.StatusTeapot)
+			}
+
+			assert.Equal(t, w.Code, tc.status)
+			assert.Equal(t, w.Header().Get("Content-Type"), tc.contentType)
+		})
+	}
+}
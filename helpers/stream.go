@@ -0,0 +1,137 @@
+package helpers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// StreamOptions configures StreamJSON and StreamNDJSON.
+// FlushEvery is the number of items written between flushes and defaults to 100.
+// Headers are added to the response before the status code is written.
+type StreamOptions struct {
+	FlushEvery int
+	Headers    http.Header
+}
+
+// StreamJSON writes the items produced by the iterator as a JSON array, encoding and
+// flushing them as they are produced instead of building the whole response in memory.
+// The iterator has the shape of iter.Seq, so a range-over-func iterator can be passed directly;
+// SeqFromChannel adapts a channel.
+// Streaming stops when the request context is done, in which case the context error is returned.
+// Because the status code is sent before the first item, an encoding error ends the stream early
+// and is returned, leaving the response incomplete.
+func StreamJSON[T any](w http.ResponseWriter, r *http.Request, status int, items func(yield func(T) bool), opts StreamOptions) error {
+	return stream(w, r, status, "application/json", items, opts, "[", ",", "]\n")
+}
+
+// StreamNDJSON writes the items produced by the iterator as newline-delimited JSON (application/x-ndjson),
+// one item per line. It streams, flushes and stops in the same way as StreamJSON.
+func StreamNDJSON[T any](w http.ResponseWriter, r *http.Request, status int, items func(yield func(T) bool), opts StreamOptions) error {
+	return stream(w, r, status, "application/x-ndjson", items, opts, "", "\n", "\n")
+}
+
+// SeqFromChannel returns an iterator over the values received from the channel until it is closed
+// or the context is done, so a stream waiting on a stalled channel stops when the client disconnects.
+// The request context should be passed when streaming a response.
+func SeqFromChannel[T any](ctx context.Context, ch <-chan T) func(yield func(T) bool) {
+	return func(yield func(T) bool) {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case item, ok := <-ch:
+				if !ok || !yield(item) {
+					return
+				}
+			}
+		}
+	}
+}
+
+// stream writes the items between the opening and closing text, separated by sep.
+// The closing text is only written when at least one item or an opening text was written.
+func stream[T any](w http.ResponseWriter, r *http.Request, status int, contentType string, items func(yield func(T) bool), opts StreamOptions, open, sep, end string) error {
+	flushEvery := opts.FlushEvery
+	if flushEvery <= 0 {
+		flushEvery = 100
+	}
+
+	for key, values := range opts.Headers {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+
+	if opts.Headers.Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	w.WriteHeader(status)
+
+	rc := http.NewResponseController(w)
+	ctx := r.Context()
+
+	_, err := w.Write([]byte(open))
+	if err != nil {
+		return err
+	}
+
+	count := 0
+
+	items(func(item T) bool {
+		if err = ctx.Err(); err != nil {
+			return false
+		}
+
+		var js []byte
+		js, err = json.Marshal(item)
+		if err != nil {
+			return false
+		}
+
+		if count > 0 {
+			js = append([]byte(sep), js...)
+		}
+
+		_, err = w.Write(js)
+		if err != nil {
+			return false
+		}
+
+		count++
+
+		if count%flushEvery == 0 {
+			err = flush(rc)
+		}
+
+		return err == nil
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if err = ctx.Err(); err != nil {
+		return err
+	}
+
+	if count > 0 || open != "" {
+		_, err = w.Write([]byte(end))
+		if err != nil {
+			return err
+		}
+	}
+
+	return flush(rc)
+}
+
+// flush sends buffered data to the client, ignoring writers that do not support flushing.
+func flush(rc *http.ResponseController) error {
+	err := rc.Flush()
+	if errors.Is(err, http.ErrNotSupported) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,126 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/windevkay/flhoutils/assert"
+)
+
+type streamedMovie struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+func moviesSeq(n int) func(yield func(streamedMovie) bool) {
+	return func(yield func(streamedMovie) bool) {
+		for i := 1; i <= n; i++ {
+			if !yield(streamedMovie{ID: i, Title: "Movie"}) {
+				return
+			}
+		}
+	}
+}
+
+func TestStreamJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		stream      func(w http.ResponseWriter, r *http.Request) error
+		count       int
+		contentType string
+		want        string
+	}{
+		{
+			name: "JSON array",
+			stream: func(w http.ResponseWriter, r *http.Request) error {
+				return StreamJSON(w, r, http.StatusOK, moviesSeq(2), StreamOptions{FlushEvery: 1})
+			},
+			contentType: "application/json",
+			want:        `[{"id":1,"title":"Movie"},{"id":2,"title":"Movie"}]` + "\n",
+		},
+		{
+			name: "Empty JSON array",
+			stream: func(w http.ResponseWriter, r *http.Request) error {
+				return StreamJSON(w, r, http.StatusOK, moviesSeq(0), StreamOptions{})
+			},
+			contentType: "application/json",
+			want:        "[]\n",
+		},
+		{
+			name: "NDJSON from a channel",
+			stream: func(w http.ResponseWriter, r *http.Request) error {
+				ch := make(chan streamedMovie, 2)
+				ch <- streamedMovie{ID: 1, Title: "Movie"}
+				ch <- streamedMovie{ID: 2, Title: "Movie"}
+				close(ch)
+				return StreamNDJSON(w, r, http.StatusOK, SeqFromChannel(r.Context(), ch), StreamOptions{})
+			},
+			contentType: "application/x-ndjson",
+			want:        `{"id":1,"title":"Movie"}` + "\n" + `{"id":2,"title":"Movie"}` + "\n",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+			err := tc.stream(w, r)
+
+			assert.Equal(t, err, nil)
+			assert.Equal(t, w.Code, http.StatusOK)
+			assert.Equal(t, w.Header().Get("Content-Type"), tc.contentType)
+			assert.Equal(t, w.Body.String(), tc.want)
+			assert.Equal(t, w.Flushed, true)
+		})
+	}
+}
+
+func TestStreamJSONStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+
+	produced := 0
+	items := func(yield func(int) bool) {
+		for i := 0; i < 100; i++ {
+			produced++
+			if i == 2 {
+				cancel()
+			}
+			if !yield(i) {
+				return
+			}
+		}
+	}
+
+	err := StreamJSON(w, r, http.StatusOK, items, StreamOptions{})
+
+	assert.Equal(t, errors.Is(err, context.Canceled), true)
+	assert.Equal(t, produced, 3)
+	assert.Equal(t, w.Body.String(), "[0,1")
+}
+
+func TestStreamNDJSONStalledChannel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	done := make(chan error, 1)
+	go func() {
+		done <- StreamNDJSON(w, r, http.StatusOK, SeqFromChannel(ctx, make(chan int)), StreamOptions{})
+	}()
+
+	select {
+	case err := <-done:
+		assert.Equal(t, errors.Is(err, context.Canceled), true)
+	case <-time.After(time.Second):
+		t.Fatal("Expected the stream to stop when the context was cancelled")
+	}
+}
@@ -0,0 +1,136 @@
+package middleware
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/windevkay/flhoutils/errors"
+)
+
+// ErrInvalidToken is returned by a TokenLookup when the token does not belong to a user,
+// for example because it is unknown or has expired.
+var ErrInvalidToken = stderrors.New("invalid authentication token")
+
+// TokenLookup returns the user a bearer token belongs to. It returns ErrInvalidToken, possibly wrapped,
+// for tokens that are not valid; any other error is treated as a server error.
+type TokenLookup[U any] func(ctx context.Context, token string) (U, error)
+
+// Activatable is implemented by users whose account has to be activated before they can use RequireActivated routes.
+type Activatable interface {
+	IsActivated() bool
+}
+
+type contextKey string
+
+const userContextKey = contextKey("user")
+
+// ContextWithUser returns a copy of the context carrying the authenticated user.
+func ContextWithUser[U any](ctx context.Context, user U) context.Context {
+	return context.WithValue(ctx, userContextKey, user)
+}
+
+// UserFromContext returns the authenticated user stored in the context.
+// It returns false if the request is not authenticated, or the user is not of type U or is a zero value.
+func UserFromContext[U any](ctx context.Context) (U, bool) {
+	user, ok := ctx.Value(userContextKey).(U)
+	return user, ok && !isZeroUser(user)
+}
+
+// isZeroUser reports whether the user is nil, including a typed nil pointer, or another zero value.
+func isZeroUser(user any) bool {
+	return user == nil || reflect.ValueOf(user).IsZero()
+}
+
+// Authenticate returns middleware that reads a bearer token from the Authorization header,
+// looks up its user and stores the user in the request context for UserFromContext.
+// Requests without an Authorization header are passed on unauthenticated, so RequireAuthenticated
+// decides which routes need a user. A malformed header or an invalid token is answered with
+// errors.InvalidAuthenticationTokenResponse, as is a lookup that returns a nil or zero user without an error.
+// Other lookup errors are answered with errors.ServerErrorResponse.
+// Authorization is added to the Vary header of every response.
+func Authenticate[U any](lookup TokenLookup[U]) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Authorization")
+
+			authorization := r.Header.Get("Authorization")
+			if authorization == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			token, ok := parseBearerToken(authorization)
+			if !ok {
+				errors.InvalidAuthenticationTokenResponse(w, r)
+				return
+			}
+
+			user, err := lookup(r.Context(), token)
+			if err != nil {
+				if stderrors.Is(err, ErrInvalidToken) {
+					errors.InvalidAuthenticationTokenResponse(w, r)
+				} else {
+					errors.ServerErrorResponse(w, r, err)
+				}
+				return
+			}
+
+			if isZeroUser(user) {
+				errors.InvalidAuthenticationTokenResponse(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(ContextWithUser(r.Context(), user)))
+		})
+	}
+}
+
+// parseBearerToken returns the token of an Authorization header using the Bearer scheme.
+func parseBearerToken(authorization string) (string, bool) {
+	scheme, token, found := strings.Cut(authorization, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+
+	token = strings.TrimSpace(token)
+	if token == "" || strings.ContainsAny(token, " \t") {
+		return "", false
+	}
+
+	return token, true
+}
+
+// RequireAuthenticated responds with errors.AuthenticationRequiredResponse unless Authenticate
+// has stored a user in the request context. Nil and zero users are not authenticated.
+func RequireAuthenticated(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isZeroUser(r.Context().Value(userContextKey)) {
+			errors.AuthenticationRequiredResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequireActivated responds with errors.AuthenticationRequiredResponse unless the request has a user of type U,
+// and with errors.InactiveAccountResponse if the user's account is not activated.
+func RequireActivated[U Activatable](next http.Handler) http.Handler {
+	return RequireAuthenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := UserFromContext[U](r.Context())
+		if !ok {
+			errors.AuthenticationRequiredResponse(w, r)
+			return
+		}
+
+		if !user.IsActivated() {
+			errors.InactiveAccountResponse(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	}))
+}
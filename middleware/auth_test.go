@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/windevkay/flhoutils/assert"
+)
+
+type testUser struct {
+	Name      string
+	Activated bool
+}
+
+func (u *testUser) IsActivated() bool {
+	return u.Activated
+}
+
+func lookupTestUser(ctx context.Context, token string) (*testUser, error) {
+	switch token {
+	case "active-token":
+		return &testUser{Name: "alice", Activated: true}, nil
+	case "inactive-token":
+		return &testUser{Name: "bob"}, nil
+	case "failing-token":
+		return nil, errors.New("database unavailable")
+	case "missing-user-token":
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("looking up token: %w", ErrInvalidToken)
+}
+
+func TestAuthenticate(t *testing.T) {
+	handler := Authenticate(lookupTestUser)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, ok := UserFromContext[*testUser](r.Context())
+		if !ok {
+			fmt.Fprint(w, "anonymous")
+			return
+		}
+		fmt.Fprint(w, user.Name)
+	}))
+
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+		body          string
+		authenticate  string
+	}{
+		{name: "No Authorization header", status: http.StatusOK, body: "anonymous"},
+		{name: "Valid token", authorization: "Bearer active-token", status: http.StatusOK, body: "alice"},
+		{name: "Case-insensitive scheme", authorization: "bearer active-token", status: http.StatusOK, body: "alice"},
+		{name: "Invalid token", authorization: "Bearer unknown-token", status: http.StatusUnauthorized, body: "{\n\t\"error\": \"Invalid or missing authentication token\"\n}\n", authenticate: "Bearer"},
+		{name: "Other scheme", authorization: "Basic YWxpY2U6cGFzcw==", status: http.StatusUnauthorized, authenticate: "Bearer"},
+		{name: "Missing token", authorization: "Bearer ", status: http.StatusUnauthorized, authenticate: "Bearer"},
+		{name: "Malformed token", authorization: "Bearer active-token extra", status: http.StatusUnauthorized, authenticate: "Bearer"},
+		{name: "Lookup failure", authorization: "Bearer failing-token", status: http.StatusInternalServerError},
+		{name: "Lookup without a user", authorization: "Bearer missing-user-token", status: http.StatusUnauthorized, authenticate: "Bearer"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.authorization != "" {
+				r.Header.Set("Authorization", tc.authorization)
+			}
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, w.Code, tc.status)
+			assert.Equal(t, w.Header().Get("Vary"), "Authorization")
+			assert.Equal(t, w.Header().Get("WWW-Authenticate"), tc.authenticate)
+			if tc.body != "" {
+				assert.Equal(t, w.Body.String(), tc.body)
+			}
+		})
+	}
+}
+
+func TestRequireAuthenticated(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+		body          string
+	}{
+		{name: "Anonymous request", status: http.StatusUnauthorized, body: "{\n\t\"error\": \"You must be authenticated to access this resource\"\n}\n"},
+		{name: "Authenticated request", authorization: "Bearer inactive-token", status: http.StatusNoContent},
+	}
+
+	handler := Authenticate(lookupTestUser)(RequireAuthenticated(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.authorization != "" {
+				r.Header.Set("Authorization", tc.authorization)
+			}
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, w.Code, tc.status)
+			assert.Equal(t, w.Body.String(), tc.body)
+		})
+	}
+}
+
+func TestRequireActivated(t *testing.T) {
+	tests := []struct {
+		name          string
+		authorization string
+		status        int
+		body          string
+	}{
+		{name: "Anonymous request", status: http.StatusUnauthorized, body: "{\n\t\"error\": \"You must be authenticated to access this resource\"\n}\n"},
+		{name: "Inactive account", authorization: "Bearer inactive-token", status: http.StatusForbidden, body: "{\n\t\"error\": \"Your user account must be activated to access this resource\"\n}\n"},
+		{name: "Activated account", authorization: "Bearer active-token", status: http.StatusNoContent},
+	}
+
+	handler := Authenticate(lookupTestUser)(RequireActivated[*testUser](http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.authorization != "" {
+				r.Header.Set("Authorization", tc.authorization)
+			}
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, w.Code, tc.status)
+			assert.Equal(t, w.Body.String(), tc.body)
+		})
+	}
+}
+
+func TestRequireAuthenticatedNilUser(t *testing.T) {
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	for _, handler := range []http.Handler{RequireAuthenticated(next), RequireActivated[*testUser](next)} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r = r.WithContext(ContextWithUser(r.Context(), (*testUser)(nil)))
+		handler.ServeHTTP(w, r)
+
+		assert.Equal(t, w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestUserFromContext(t *testing.T) {
+	ctx := ContextWithUser(context.Background(), &testUser{Name: "alice"})
+
+	user, ok := UserFromContext[*testUser](ctx)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, user.Name, "alice")
+
+	_, ok = UserFromContext[string](ctx)
+	assert.Equal(t, ok, false)
+
+	_, ok = UserFromContext[*testUser](context.Background())
+	assert.Equal(t, ok, false)
+
+	_, ok = UserFromContext[*testUser](ContextWithUser(context.Background(), (*testUser)(nil)))
+	assert.Equal(t, ok, false)
+}
@@ -0,0 +1,344 @@
+package middleware
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// CompressOptions configures Compress.
+// MinSize is the smallest response body, in bytes, that is compressed and defaults to 1024.
+// ContentTypes lists the media types that are compressed; entries such as "text/*" match a whole
+// main type. It defaults to JSON, problem details, XML, NDJSON and common text responses.
+// Server-sent events (text/event-stream) are left out by default, as compressed event streams
+// are buffered or broken by some proxies and clients.
+// Level is the compression level used by both encodings and defaults to flate.DefaultCompression.
+type CompressOptions struct {
+	MinSize      int
+	ContentTypes []string
+	Level        int
+}
+
+var defaultCompressibleTypes = []string{
+	"application/json",
+	"application/problem+json",
+	"application/xml",
+	"application/problem+xml",
+	"application/x-ndjson",
+	"text/plain",
+	"text/html",
+	"text/css",
+	"text/csv",
+	"text/javascript",
+	"text/markdown",
+	"text/xml",
+}
+
+// resettableWriter is implemented by *gzip.Writer and *flate.Writer.
+type resettableWriter interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// compressor holds the pooled writers of a single encoding.
+type compressor struct {
+	encoding string
+	pool     *sync.Pool
+}
+
+// Compress returns middleware that compresses responses with gzip or deflate, following the
+// client's Accept-Encoding header. Responses are buffered until MinSize bytes have been written,
+// so small responses, responses with other content types and already encoded responses are sent as they are.
+// Writers are pooled, and Accept-Encoding is added to the Vary header of every response.
+// If the handler panics, the buffered part of the response is discarded and the panic continues.
+func Compress(opts CompressOptions) func(http.Handler) http.Handler {
+	if opts.MinSize <= 0 {
+		opts.MinSize = 1024
+	}
+
+	if opts.ContentTypes == nil {
+		opts.ContentTypes = defaultCompressibleTypes
+	}
+
+	if opts.Level == 0 {
+		opts.Level = flate.DefaultCompression
+	}
+
+	compressors := map[string]*compressor{
+		"gzip": {
+			encoding: "gzip",
+			pool: &sync.Pool{New: func() any {
+				gz, err := gzip.NewWriterLevel(io.Discard, opts.Level)
+				if err != nil {
+					panic(err)
+				}
+				return gz
+			}},
+		},
+		"deflate": {
+			encoding: "deflate",
+			pool: &sync.Pool{New: func() any {
+				fw, err := flate.NewWriter(io.Discard, opts.Level)
+				if err != nil {
+					panic(err)
+				}
+				return fw
+			}},
+		},
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"))
+			if encoding == "" || r.Method == http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			cw := &compressWriter{
+				ResponseWriter: w,
+				compressor:     compressors[encoding],
+				opts:           &opts,
+				status:         http.StatusOK,
+			}
+			returned := false
+			defer func() {
+				if returned {
+					cw.close()
+				} else {
+					cw.discard()
+				}
+			}()
+
+			next.ServeHTTP(cw, r)
+			returned = true
+		})
+	}
+}
+
+// negotiateEncoding returns the preferred supported encoding of an Accept-Encoding header, or "" for none.
+// gzip is preferred over deflate when the client gives both the same quality.
+func negotiateEncoding(acceptEncoding string) string {
+	qualities := map[string]float64{}
+
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+
+		quality := 1.0
+		if q, found := strings.CutPrefix(strings.TrimSpace(params), "q="); found {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			quality = parsed
+		}
+
+		qualities[coding] = quality
+	}
+
+	best := ""
+	bestQuality := 0.0
+
+	for _, encoding := range []string{"gzip", "deflate"} {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality, ok = qualities["*"]
+		}
+
+		if ok && quality > bestQuality {
+			best = encoding
+			bestQuality = quality
+		}
+	}
+
+	return best
+}
+
+// compressWriter buffers the start of a response to decide whether it is worth compressing.
+type compressWriter struct {
+	http.ResponseWriter
+	compressor *compressor
+	opts       *CompressOptions
+
+	status      int
+	wroteHeader bool
+	decided     bool
+	buf         []byte
+	writer      resettableWriter
+}
+
+// WriteHeader records the status code; it is sent once the response has been inspected.
+func (cw *compressWriter) WriteHeader(status int) {
+	if cw.wroteHeader {
+		return
+	}
+
+	if status >= 100 && status < 200 {
+		cw.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	cw.status = status
+	cw.wroteHeader = true
+}
+
+// Write buffers the body until the compression decision can be made and then writes through.
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	cw.wroteHeader = true
+
+	if !cw.decided {
+		cw.buf = append(cw.buf, p...)
+
+		if len(cw.buf) < cw.opts.MinSize {
+			return len(p), nil
+		}
+
+		err := cw.decide(true)
+		if err != nil {
+			return 0, err
+		}
+
+		return len(p), nil
+	}
+
+	if cw.writer != nil {
+		return cw.writer.Write(p)
+	}
+
+	return cw.ResponseWriter.Write(p)
+}
+
+// decide sends the headers, compressing the response if it qualifies, and writes the buffered body.
+func (cw *compressWriter) decide(largeEnough bool) error {
+	cw.decided = true
+
+	header := cw.ResponseWriter.Header()
+
+	if header.Get("Content-Type") == "" && len(cw.buf) > 0 {
+		header.Set("Content-Type", http.DetectContentType(cw.buf))
+	}
+
+	if largeEnough && cw.compressible() {
+		header.Set("Content-Encoding", cw.compressor.encoding)
+		header.Del("Content-Length")
+
+		cw.writer = cw.compressor.pool.Get().(resettableWriter)
+		cw.writer.Reset(cw.ResponseWriter)
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.status)
+
+	if len(cw.buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if cw.writer != nil {
+		_, err = cw.writer.Write(cw.buf)
+	} else {
+		_, err = cw.ResponseWriter.Write(cw.buf)
+	}
+
+	cw.buf = nil
+
+	return err
+}
+
+// compressible reports whether the status, content type and existing encoding allow compression.
+func (cw *compressWriter) compressible() bool {
+	if cw.status < http.StatusOK || cw.status == http.StatusNoContent || cw.status == http.StatusNotModified {
+		return false
+	}
+
+	header := cw.ResponseWriter.Header()
+
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+
+	for _, allowed := range cw.opts.ContentTypes {
+		if mainType, found := strings.CutSuffix(allowed, "/*"); found {
+			if strings.HasPrefix(mediaType, mainType+"/") {
+				return true
+			}
+		} else if mediaType == allowed {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Flush sends any buffered data to the client. A response flushed before reaching MinSize
+// is compressed if it otherwise qualifies, as it is likely to be streamed.
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if cw.decide(true) != nil {
+			return
+		}
+	}
+
+	if cw.writer != nil {
+		if cw.writer.Flush() != nil {
+			return
+		}
+	}
+
+	http.NewResponseController(cw.ResponseWriter).Flush()
+}
+
+// Hijack lets the handler take over the connection when the underlying writer supports it.
+func (cw *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(cw.ResponseWriter).Hijack()
+}
+
+// Unwrap returns the underlying response writer for http.ResponseController.
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// discard drops the part of the response that has not been sent when the handler panics, so
+// an outer recover can still send its own response if the status has not been written yet.
+func (cw *compressWriter) discard() {
+	cw.buf = nil
+
+	if cw.writer != nil {
+		cw.writer.Reset(io.Discard)
+		cw.compressor.pool.Put(cw.writer)
+		cw.writer = nil
+	}
+}
+
+// close finishes the response, sending small responses uncompressed and returning the writer to the pool.
+func (cw *compressWriter) close() {
+	if !cw.decided {
+		if !cw.wroteHeader {
+			return
+		}
+
+		cw.decide(false)
+	}
+
+	if cw.writer != nil {
+		cw.writer.Close()
+		cw.writer.Reset(io.Discard)
+		cw.compressor.pool.Put(cw.writer)
+		cw.writer = nil
+	}
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/windevkay/flhoutils/assert"
+	"github.com/windevkay/flhoutils/helpers"
+)
+
+func TestCompress(t *testing.T) {
+	large := strings.Repeat("a", 2048)
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		contentType    string
+		body           string
+		encoding       string
+	}{
+		{name: "Gzip for large JSON", acceptEncoding: "gzip, deflate", contentType: "application/json", body: large, encoding: "gzip"},
+		{name: "Deflate when preferred", acceptEncoding: "gzip;q=0.5, deflate", contentType: "application/json", body: large, encoding: "deflate"},
+		{name: "Wildcard encoding", acceptEncoding: "*", contentType: "text/plain", body: large, encoding: "gzip"},
+		{name: "Small responses are not compressed", acceptEncoding: "gzip", contentType: "application/json", body: "{}", encoding: ""},
+		{name: "Other content types are not compressed", acceptEncoding: "gzip", contentType: "image/png", body: large, encoding: ""},
+		{name: "Client without compression", acceptEncoding: "", contentType: "application/json", body: large, encoding: ""},
+		{name: "Refused encodings", acceptEncoding: "gzip;q=0, deflate;q=0", contentType: "application/json", body: large, encoding: ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := Compress(CompressOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				w.WriteHeader(http.StatusCreated)
+				io.WriteString(w, tc.body[:len(tc.body)/2])
+				io.WriteString(w, tc.body[len(tc.body)/2:])
+			}))
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, w.Code, http.StatusCreated)
+			assert.Equal(t, w.Header().Get("Content-Encoding"), tc.encoding)
+			assert.Equal(t, w.Header().Get("Vary"), "Accept-Encoding")
+
+			var body io.Reader = w.Body
+			switch tc.encoding {
+			case "gzip":
+				gz, err := gzip.NewReader(w.Body)
+				if err != nil {
+					t.Fatalf("Failed to read gzip body: %v", err)
+				}
+				body = gz
+			case "deflate":
+				body = flate.NewReader(w.Body)
+			}
+
+			decoded, err := io.ReadAll(body)
+			if err != nil {
+				t.Fatalf("Failed to read body: %v", err)
+			}
+			assert.Equal(t, string(decoded), tc.body)
+		})
+	}
+}
+
+func TestCompressWriteJSON(t *testing.T) {
+	handler := Compress(CompressOptions{MinSize: 10})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		helpers.WriteJSON(w, http.StatusOK, helpers.Envelope{"movie": "Up"}, nil)
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, w.Header().Get("Content-Encoding"), "gzip")
+	assert.Equal(t, w.Header().Get("Content-Type"), "application/json")
+
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatalf("Failed to read gzip body: %v", err)
+	}
+	decoded, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatalf("Failed to read body: %v", err)
+	}
+	assert.Equal(t, string(decoded), "{\n\t\"movie\": \"Up\"\n}\n")
+}
+
+func TestCompressEventStream(t *testing.T) {
+	handler := Compress(CompressOptions{MinSize: 10})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, err := helpers.NewSSEWriter(w, r)
+		if err != nil {
+			t.Fatalf("Failed to start event stream: %v", err)
+		}
+
+		s.Send(helpers.Event{Data: helpers.Envelope{"movie": strings.Repeat("a", 64)}})
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/events", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, w.Header().Get("Content-Type"), "text/event-stream")
+	assert.Equal(t, w.Header().Get("Content-Encoding"), "")
+	assert.Equal(t, w.Body.String(), "data: {\"movie\":\""+strings.Repeat("a", 64)+"\"}\n\n")
+}
+
+func TestCompressPanic(t *testing.T) {
+	handler := RecoverPanic(Compress(CompressOptions{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, `{"partial":`)
+		panic("boom")
+	})))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, w.Code, http.StatusInternalServerError)
+	assert.Equal(t, w.Header().Get("Content-Encoding"), "")
+	assert.Equal(t, strings.HasPrefix(w.Body.String(), "{\n\t\"error\": "), true)
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/windevkay/flhoutils/errors"
+)
+
+// KeyFunc identifies the client a request is counted against.
+// Requests for which it returns an empty key are not rate limited.
+type KeyFunc func(r *http.Request) string
+
+// RateLimitAlgorithm selects how RateLimit counts the requests of a client.
+type RateLimitAlgorithm int
+
+const (
+	// TokenBucket allows bursts of Burst requests and refills at Rate requests per second.
+	TokenBucket RateLimitAlgorithm = iota
+	// SlidingWindow allows Limit requests in any Window, weighting the previous window's count
+	// by how much of it overlaps the sliding window.
+	SlidingWindow
+)
+
+// RateLimitOptions configures RateLimit.
+// Algorithm defaults to TokenBucket.
+// Rate is the number of requests per second each client is allowed on average and defaults to 2.
+// Burst is the number of requests a client can make at once and defaults to 4.
+// Limit is the number of requests allowed per Window by SlidingWindow and defaults to 60.
+// Window defaults to one minute.
+// Key identifies the client and defaults to KeyByIP.
+// Store records the requests of every client and defaults to a MemoryStore that forgets
+// clients idle for three minutes and keeps at most 100000 clients. A shared store, such as a RedisStore, limits clients
+// across every replica of a service.
+type RateLimitOptions struct {
+	Algorithm RateLimitAlgorithm
+	Rate      float64
+	Burst     int
+	Limit     int
+	Window    time.Duration
+	Key       KeyFunc
+	Store     RateLimitStore
+}
+
+// KeyByIP identifies clients by the IP address of the connection.
+// Behind a proxy, a KeyFunc that reads the header set by the proxy should be used instead.
+func KeyByIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+
+	return ip
+}
+
+// KeyByHeader identifies clients by the value of a request header, such as an API key.
+// Header values are chosen by the client, so valid must only accept verified keys, for example
+// API keys known to the service; otherwise a client could send a new value with every request
+// to get a fresh allowance. Requests without the header or with a value valid rejects are
+// identified by their IP address instead.
+func KeyByHeader(name string, valid func(value string) bool) KeyFunc {
+	if valid == nil {
+		panic("middleware: KeyByHeader requires a validation function")
+	}
+
+	return func(r *http.Request) string {
+		if value := r.Header.Get(name); value != "" && valid(value) {
+			return name + ":" + value
+		}
+
+		return KeyByIP(r)
+	}
+}
+
+// RateLimit returns middleware that limits the requests of each client with the selected algorithm.
+// When a request is refused, errors.RateLimitExceededResponse is sent with a Retry-After header.
+// The RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers are added to every limited response.
+// If the store fails, the request is refused with errors.ServerErrorResponse rather than let through,
+// so an outage of a shared store cannot lift the limits; RedisStore retries connections closed while idle.
+func RateLimit(opts RateLimitOptions) func(http.Handler) http.Handler {
+	if opts.Rate <= 0 {
+		opts.Rate = 2
+	}
+
+	if opts.Burst <= 0 {
+		opts.Burst = 4
+	}
+
+	if opts.Limit <= 0 {
+		opts.Limit = 60
+	}
+
+	if opts.Window <= 0 {
+		opts.Window = time.Minute
+	}
+
+	if opts.Key == nil {
+		opts.Key = KeyByIP
+	}
+
+	if opts.Store == nil {
+		opts.Store = NewMemoryStore(3*time.Minute, 100000)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := opts.Key(r)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var result RateLimitResult
+			var err error
+
+			if opts.Algorithm == SlidingWindow {
+				result, err = opts.Store.SlidingWindow(r.Context(), key, opts.Limit, opts.Window)
+			} else {
+				result, err = opts.Store.TokenBucket(r.Context(), key, opts.Rate, opts.Burst)
+			}
+
+			if err != nil {
+				errors.ServerErrorResponse(w, r, err)
+				return
+			}
+
+			header := w.Header()
+			header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+			if !result.Allowed {
+				header.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				errors.RateLimitExceededResponse(w, r)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// ceilSeconds rounds a duration up to whole seconds.
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,235 @@
+package middleware
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimitStore records the requests of every client for RateLimit.
+// Both methods count a request against the key if it is allowed; refused requests are not counted.
+type RateLimitStore interface {
+	// TokenBucket takes a token from a bucket holding burst tokens and refilled at rate tokens per second.
+	TokenBucket(ctx context.Context, key string, rate float64, burst int) (RateLimitResult, error)
+	// SlidingWindow counts the request against a limit of requests per window.
+	SlidingWindow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error)
+}
+
+// RateLimitResult is the outcome of counting a request.
+// Reset is the time until the client's allowance is fully restored and
+// RetryAfter, set when the request is refused, the time until a request would be allowed.
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// takeToken refills a bucket that held the given tokens for the elapsed time and takes a token if one is available.
+// A negative elapsed time, from a clock that went backwards, refills nothing. It returns the tokens left in the bucket.
+func takeToken(tokens float64, elapsed time.Duration, rate float64, burst int) (float64, RateLimitResult) {
+	tokens = math.Min(float64(burst), tokens+max(elapsed, 0).Seconds()*rate)
+
+	result := RateLimitResult{Limit: burst}
+
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+
+	result.Remaining = int(tokens)
+	result.Reset = secondsToDuration((float64(burst) - tokens) / rate)
+
+	return tokens, result
+}
+
+// slidingWindow decides whether a request is allowed given the number of requests counted in the previous
+// and current fixed windows, and the time elapsed since the current window started. The previous count is
+// weighted by the part of the previous window that still falls within the sliding window.
+func slidingWindow(previous, current int64, elapsed, window time.Duration, limit int) RateLimitResult {
+	weight := 1 - float64(elapsed)/float64(window)
+	count := float64(previous)*weight + float64(current)
+
+	result := RateLimitResult{Limit: limit, Reset: window - elapsed}
+
+	if count+1 <= float64(limit) {
+		count++
+		result.Allowed = true
+	} else {
+		excess := count + 1 - float64(limit)
+
+		if previous > 0 && excess <= float64(previous)*weight {
+			result.RetryAfter = time.Duration(excess / float64(previous) * float64(window))
+		} else {
+			result.RetryAfter = window - elapsed
+		}
+	}
+
+	result.Remaining = max(0, limit-int(math.Ceil(count)))
+
+	return result
+}
+
+// secondsToDuration converts a number of seconds to a duration.
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// bucket is the token bucket of a single client.
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// windowCounter holds the request counts of a client's current and previous fixed windows.
+type windowCounter struct {
+	index    int64
+	current  int64
+	previous int64
+}
+
+// memoryClient is a client of a MemoryStore. It is kept for keepFor after it was last seen
+// and holds either a *bucket or a *windowCounter.
+type memoryClient struct {
+	key      string
+	lastSeen time.Time
+	keepFor  time.Duration
+	state    any
+}
+
+// MemoryStore is a RateLimitStore that keeps every client in memory.
+// It only limits the requests handled by a single process.
+type MemoryStore struct {
+	mu         sync.Mutex
+	clients    map[string]*list.Element
+	recent     *list.List
+	staleAfter time.Duration
+	maxClients int
+	now        func() time.Time
+}
+
+// NewMemoryStore returns a MemoryStore that forgets clients idle for the staleAfter duration,
+// which defaults to three minutes. Sliding window clients are kept for at least two windows.
+// At most maxClients clients, 100000 by default, are kept: when the store is full, the least
+// recently seen client is forgotten to make room for a new one, and starts over with a full
+// allowance on its next request.
+func NewMemoryStore(staleAfter time.Duration, maxClients int) *MemoryStore {
+	if staleAfter <= 0 {
+		staleAfter = 3 * time.Minute
+	}
+
+	if maxClients <= 0 {
+		maxClients = 100000
+	}
+
+	return &MemoryStore{
+		clients:    make(map[string]*list.Element),
+		recent:     list.New(),
+		staleAfter: staleAfter,
+		maxClients: maxClients,
+		now:        time.Now,
+	}
+}
+
+// TokenBucket implements RateLimitStore.
+func (s *MemoryStore) TokenBucket(ctx context.Context, key string, rate float64, burst int) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	client := s.client("bucket:"+key, now, s.staleAfter)
+
+	b, ok := client.state.(*bucket)
+	if !ok {
+		b = &bucket{tokens: float64(burst), lastSeen: now}
+		client.state = b
+	}
+
+	var result RateLimitResult
+	b.tokens, result = takeToken(b.tokens, now.Sub(b.lastSeen), rate, burst)
+	b.lastSeen = now
+
+	return result, nil
+}
+
+// SlidingWindow implements RateLimitStore.
+func (s *MemoryStore) SlidingWindow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	client := s.client("window:"+key, now, max(s.staleAfter, 2*window))
+
+	index := now.UnixNano() / int64(window)
+
+	c, ok := client.state.(*windowCounter)
+	if !ok {
+		c = &windowCounter{index: index}
+		client.state = c
+	}
+
+	switch index - c.index {
+	case 0:
+	case 1:
+		c.previous, c.current = c.current, 0
+	default:
+		c.previous, c.current = 0, 0
+	}
+
+	c.index = index
+
+	result := slidingWindow(c.previous, c.current, time.Duration(now.UnixNano()-index*int64(window)), window, limit)
+	if result.Allowed {
+		c.current++
+	}
+
+	return result, nil
+}
+
+// client returns the client with the given key, marking it as seen now, after evicting idle clients.
+// A new client evicts the least recently seen one if the store is full.
+func (s *MemoryStore) client(key string, now time.Time, keepFor time.Duration) *memoryClient {
+	s.sweep(now)
+
+	if element, ok := s.clients[key]; ok {
+		s.recent.MoveToFront(element)
+
+		client := element.Value.(*memoryClient)
+		client.lastSeen = now
+		client.keepFor = keepFor
+
+		return client
+	}
+
+	if s.recent.Len() >= s.maxClients {
+		s.remove(s.recent.Back())
+	}
+
+	client := &memoryClient{key: key, lastSeen: now, keepFor: keepFor}
+	s.clients[key] = s.recent.PushFront(client)
+
+	return client
+}
+
+// sweep evicts idle clients from the least recently seen, stopping at the first client still kept.
+func (s *MemoryStore) sweep(now time.Time) {
+	for element := s.recent.Back(); element != nil; element = s.recent.Back() {
+		client := element.Value.(*memoryClient)
+		if now.Sub(client.lastSeen) < client.keepFor {
+			return
+		}
+
+		s.remove(element)
+	}
+}
+
+// remove forgets the client of a list element.
+func (s *MemoryStore) remove(element *list.Element) {
+	s.recent.Remove(element)
+	delete(s.clients, element.Value.(*memoryClient).key)
+}
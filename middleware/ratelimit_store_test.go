@@ -0,0 +1,159 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/windevkay/flhoutils/assert"
+)
+
+func TestMemoryStoreTokenBucket(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store := NewMemoryStore(time.Minute, 0)
+	store.now = func() time.Time { return now }
+
+	tests := []struct {
+		name       string
+		elapsed    time.Duration
+		allowed    bool
+		remaining  int
+		reset      time.Duration
+		retryAfter time.Duration
+	}{
+		{name: "Full bucket", allowed: true, remaining: 2, reset: 500 * time.Millisecond},
+		{name: "Second token", allowed: true, remaining: 1, reset: time.Second},
+		{name: "Last token", allowed: true, remaining: 0, reset: 1500 * time.Millisecond},
+		{name: "Empty bucket", elapsed: 250 * time.Millisecond, allowed: false, remaining: 0, reset: 1250 * time.Millisecond, retryAfter: 250 * time.Millisecond},
+		{name: "Refilled token", elapsed: 250 * time.Millisecond, allowed: true, remaining: 0, reset: 1500 * time.Millisecond},
+		{name: "Refill is capped at the burst", elapsed: time.Hour, allowed: true, remaining: 2, reset: 500 * time.Millisecond},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			now = now.Add(tc.elapsed)
+
+			result, err := store.TokenBucket(context.Background(), "client", 2, 3)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			assert.Equal(t, result.Allowed, tc.allowed)
+			assert.Equal(t, result.Limit, 3)
+			assert.Equal(t, result.Remaining, tc.remaining)
+			assert.Equal(t, result.Reset, tc.reset)
+			assert.Equal(t, result.RetryAfter, tc.retryAfter)
+		})
+	}
+}
+
+func TestMemoryStoreSlidingWindow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store := NewMemoryStore(time.Minute, 0)
+	store.now = func() time.Time { return now }
+
+	tests := []struct {
+		name       string
+		elapsed    time.Duration
+		allowed    bool
+		remaining  int
+		reset      time.Duration
+		retryAfter time.Duration
+	}{
+		{name: "First request", allowed: true, remaining: 2, reset: 10 * time.Second},
+		{name: "Second request", elapsed: 2 * time.Second, allowed: true, remaining: 1, reset: 8 * time.Second},
+		{name: "Last request", elapsed: 2 * time.Second, allowed: true, remaining: 0, reset: 6 * time.Second},
+		{name: "Limit reached", elapsed: 2 * time.Second, allowed: false, remaining: 0, reset: 4 * time.Second, retryAfter: 4 * time.Second},
+		{name: "Previous window is weighted", elapsed: 6 * time.Second, allowed: false, remaining: 0, reset: 8 * time.Second, retryAfter: 4 * time.Second / 3},
+		{name: "Previous window has slid past", elapsed: 5 * time.Second, allowed: true, remaining: 1, reset: 3 * time.Second},
+		{name: "Windows are reset after idling", elapsed: time.Minute, allowed: true, remaining: 2, reset: 3 * time.Second},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			now = now.Add(tc.elapsed)
+
+			result, err := store.SlidingWindow(context.Background(), "client", 3, 10*time.Second)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			assert.Equal(t, result.Allowed, tc.allowed)
+			assert.Equal(t, result.Limit, 3)
+			assert.Equal(t, result.Remaining, tc.remaining)
+			assert.Equal(t, result.Reset, tc.reset)
+			assert.Equal(t, result.RetryAfter, tc.retryAfter)
+		})
+	}
+}
+
+func TestMemoryStoreEviction(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store := NewMemoryStore(time.Minute, 0)
+	store.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	store.TokenBucket(ctx, "idle", 1, 1)
+
+	now = now.Add(30 * time.Second)
+	store.TokenBucket(ctx, "active", 1, 1)
+
+	now = now.Add(40 * time.Second)
+	store.TokenBucket(ctx, "active", 1, 1)
+	store.SlidingWindow(ctx, "window", 1, 10*time.Second)
+
+	_, idle := store.clients["bucket:idle"]
+	_, active := store.clients["bucket:active"]
+	_, window := store.clients["window:window"]
+
+	assert.Equal(t, idle, false)
+	assert.Equal(t, active, true)
+	assert.Equal(t, window, true)
+}
+
+func TestMemoryStoreDefaultStaleAfter(t *testing.T) {
+	for _, staleAfter := range []time.Duration{0, -time.Second} {
+		store := NewMemoryStore(staleAfter, 0)
+
+		for i := range 3 {
+			result, err := store.TokenBucket(context.Background(), "client", 0.001, 1)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			assert.Equal(t, result.Allowed, i == 0)
+		}
+
+		assert.Equal(t, store.staleAfter, 3*time.Minute)
+		assert.Equal(t, store.maxClients, 100000)
+	}
+}
+
+func TestMemoryStoreMaxClients(t *testing.T) {
+	store := NewMemoryStore(time.Minute, 2)
+
+	ctx := context.Background()
+	store.TokenBucket(ctx, "first", 0.001, 1)
+	store.TokenBucket(ctx, "second", 0.001, 1)
+	store.TokenBucket(ctx, "first", 0.001, 1)
+	store.TokenBucket(ctx, "third", 0.001, 1)
+
+	_, first := store.clients["bucket:first"]
+	_, second := store.clients["bucket:second"]
+	_, third := store.clients["bucket:third"]
+
+	assert.Equal(t, first, true)
+	assert.Equal(t, second, false)
+	assert.Equal(t, third, true)
+
+	for i := range 10 {
+		store.TokenBucket(ctx, fmt.Sprintf("forged-%d", i), 0.001, 1)
+	}
+
+	assert.Equal(t, len(store.clients), 2)
+	assert.Equal(t, store.recent.Len(), 2)
+}
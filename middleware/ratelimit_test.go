@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/windevkay/flhoutils/assert"
+)
+
+func TestRateLimit(t *testing.T) {
+	handler := RateLimit(RateLimitOptions{Rate: 0.5, Burst: 2})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		status     int
+		remaining  string
+		retryAfter string
+	}{
+		{name: "First request", remoteAddr: "192.0.2.1:1234", status: http.StatusNoContent, remaining: "1"},
+		{name: "Burst is allowed", remoteAddr: "192.0.2.1:5678", status: http.StatusNoContent, remaining: "0"},
+		{name: "Exhausted bucket", remoteAddr: "192.0.2.1:1234", status: http.StatusTooManyRequests, remaining: "0", retryAfter: "2"},
+		{name: "Other clients are unaffected", remoteAddr: "192.0.2.2:1234", status: http.StatusNoContent, remaining: "1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tc.remoteAddr
+			handler.ServeHTTP(w, r)
+
+			assert.Equal(t, w.Code, tc.status)
+			assert.Equal(t, w.Header().Get("RateLimit-Limit"), "2")
+			assert.Equal(t, w.Header().Get("RateLimit-Remaining"), tc.remaining)
+			assert.Equal(t, w.Header().Get("Retry-After"), tc.retryAfter)
+		})
+	}
+}
+
+func knownAPIKey(value string) bool {
+	return value == "abc" || value == "secret"
+}
+
+func TestRateLimitResponse(t *testing.T) {
+	handler := RateLimit(RateLimitOptions{Rate: 1, Burst: 1, Key: KeyByHeader("X-Api-Key", knownAPIKey)})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for range 2 {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-Api-Key", "secret")
+		handler.ServeHTTP(w, r)
+
+		if w.Code == http.StatusTooManyRequests {
+			assert.Equal(t, w.Body.String(), "{\n\t\"error\": \"Rate limit exceeded\"\n}\n")
+			return
+		}
+	}
+
+	t.Fatal("Expected the second request to be rate limited")
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        KeyFunc
+		remoteAddr string
+		apiKey     string
+		expected   string
+	}{
+		{name: "IP address", key: KeyByIP, remoteAddr: "192.0.2.1:1234", expected: "192.0.2.1"},
+		{name: "IPv6 address", key: KeyByIP, remoteAddr: "[2001:db8::1]:1234", expected: "2001:db8::1"},
+		{name: "Address without port", key: KeyByIP, remoteAddr: "192.0.2.1", expected: "192.0.2.1"},
+		{name: "API key", key: KeyByHeader("X-Api-Key", knownAPIKey), remoteAddr: "192.0.2.1:1234", apiKey: "abc", expected: "X-Api-Key:abc"},
+		{name: "Missing API key", key: KeyByHeader("X-Api-Key", knownAPIKey), remoteAddr: "192.0.2.1:1234", expected: "192.0.2.1"},
+		{name: "Unknown API key", key: KeyByHeader("X-Api-Key", knownAPIKey), remoteAddr: "192.0.2.1:1234", apiKey: "forged", expected: "192.0.2.1"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tc.remoteAddr
+			if tc.apiKey != "" {
+				r.Header.Set("X-Api-Key", tc.apiKey)
+			}
+
+			assert.Equal(t, tc.key(r), tc.expected)
+		})
+	}
+}
+
+func TestRateLimitUnkeyedRequests(t *testing.T) {
+	handler := RateLimit(RateLimitOptions{Rate: 1, Burst: 1, Key: func(r *http.Request) string { return "" }})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for range 3 {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, w.Code, http.StatusNoContent)
+		assert.Equal(t, w.Header().Get("RateLimit-Limit"), "")
+	}
+}
+
+func TestRateLimitSlidingWindow(t *testing.T) {
+	handler := RateLimit(RateLimitOptions{Algorithm: SlidingWindow, Limit: 2, Window: time.Hour})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	statuses := []int{http.StatusNoContent, http.StatusNoContent, http.StatusTooManyRequests}
+
+	for _, status := range statuses {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, w.Code, status)
+		assert.Equal(t, w.Header().Get("RateLimit-Limit"), "2")
+	}
+}
+
+func TestRateLimitStoreError(t *testing.T) {
+	store := NewRedisStore(RedisOptions{Addr: "127.0.0.1:1", Timeout: 100 * time.Millisecond})
+	defer store.Close()
+
+	handler := RateLimit(RateLimitOptions{Store: store})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	assert.Equal(t, w.Code, http.StatusInternalServerError)
+}
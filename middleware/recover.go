@@ -0,0 +1,31 @@
+package middleware
+
+import (
+	"net/http"
+	"runtime/debug"
+
+	"github.com/windevkay/flhoutils/errors"
+	"github.com/windevkay/flhoutils/helpers"
+)
+
+// RecoverPanic recovers panics raised by the next handler and responds with errors.ServerErrorResponse.
+// It sets the "Connection: close" header so the server closes the connection after the response,
+// and passes the panic value and stack trace to the errors package logger as a helpers.PanicError.
+// When no logger has been set with errors.SetLogger, the panic is logged through slog.Default.
+// http.ErrAbortHandler is re-raised so the server can abort the response as intended.
+func RecoverPanic(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				w.Header().Set("Connection", "close")
+				errors.ServerErrorResponse(w, r, &helpers.PanicError{Value: recovered, Stack: debug.Stack()})
+			}
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
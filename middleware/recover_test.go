@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/windevkay/flhoutils/assert"
+	"github.com/windevkay/flhoutils/errors"
+)
+
+func TestRecoverPanic(t *testing.T) {
+	var buf bytes.Buffer
+	errors.SetLogger(slog.New(slog.NewJSONHandler(&buf, nil)))
+	defer errors.SetLogger(nil)
+
+	handler := RecoverPanic(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("database password is hunter2")
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, w.Code, http.StatusInternalServerError)
+	assert.Equal(t, w.Header().Get("Connection"), "close")
+	assert.Equal(t, strings.Contains(w.Body.String(), "hunter2"), false)
+
+	var entry map[string]interface{}
+	err := json.Unmarshal(buf.Bytes(), &entry)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal log entry: %v", err)
+	}
+
+	assert.Equal(t, entry["error"], interface{}("panic: database password is hunter2"))
+	assert.Equal(t, strings.Contains(entry["stack"].(string), "TestRecoverPanic"), true)
+}
+
+func TestRecoverPanicDefaultLogger(t *testing.T) {
+	var buf bytes.Buffer
+	defaultLogger := slog.Default()
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&buf, nil)))
+	defer slog.SetDefault(defaultLogger)
+
+	handler := RecoverPanic(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("unexpected nil movie")
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, w.Code, http.StatusInternalServerError)
+
+	var entry map[string]interface{}
+	err := json.Unmarshal(buf.Bytes(), &entry)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal log entry: %v", err)
+	}
+
+	assert.Equal(t, entry["level"], interface{}("ERROR"))
+	assert.Equal(t, entry["error"], interface{}("panic: unexpected nil movie"))
+}
+
+func TestRecoverPanicPassesThrough(t *testing.T) {
+	handler := RecoverPanic(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	handler.ServeHTTP(w, r)
+
+	assert.Equal(t, w.Code, http.StatusNoContent)
+	assert.Equal(t, w.Header().Get("Connection"), "")
+}
@@ -0,0 +1,529 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrRedisContention is returned by RedisStore.TokenBucket when a bucket kept being updated
+// by other requests while it was being read.
+var ErrRedisContention = errors.New("redis: too many concurrent updates to the rate limit key")
+
+// redisTransactionAttempts is the number of times a token bucket update is retried after a conflict.
+const redisTransactionAttempts = 5
+
+// RedisOptions configures a RedisStore.
+// Addr defaults to localhost:6379. Username and Password are sent with AUTH when a password is set,
+// and DB is selected when it is not zero.
+// Prefix is prepended to every key and defaults to "ratelimit:".
+// Timeout bounds connecting and each operation and defaults to one second.
+// MaxIdleConns is the number of connections kept open between requests and defaults to 10.
+type RedisOptions struct {
+	Addr         string
+	Username     string
+	Password     string
+	DB           int
+	Prefix       string
+	Timeout      time.Duration
+	MaxIdleConns int
+}
+
+// RedisError is an error reply sent by the Redis server.
+type RedisError string
+
+// Error returns the message of the error reply.
+func (e RedisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// RedisStore is a RateLimitStore kept in Redis, or any server speaking the Redis protocol,
+// so that clients are limited across every process sharing it.
+// Sliding window counters use INCR and PEXPIRE, and token buckets are updated in
+// WATCH/MULTI/EXEC transactions. Time is read from the server with TIME, so the clocks of the
+// processes sharing the store do not have to agree.
+type RedisStore struct {
+	opts   RedisOptions
+	idle   chan *redisConn
+	mu     sync.Mutex
+	closed bool
+}
+
+// NewRedisStore returns a RedisStore. Connections are opened when they are first needed.
+func NewRedisStore(opts RedisOptions) *RedisStore {
+	if opts.Addr == "" {
+		opts.Addr = "localhost:6379"
+	}
+
+	if opts.Prefix == "" {
+		opts.Prefix = "ratelimit:"
+	}
+
+	if opts.Timeout <= 0 {
+		opts.Timeout = time.Second
+	}
+
+	if opts.MaxIdleConns <= 0 {
+		opts.MaxIdleConns = 10
+	}
+
+	return &RedisStore{
+		opts: opts,
+		idle: make(chan *redisConn, opts.MaxIdleConns),
+	}
+}
+
+// Close closes the idle connections. Connections in use are closed when they are released.
+func (s *RedisStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+
+	var errs []error
+
+	for {
+		select {
+		case c := <-s.idle:
+			errs = append(errs, c.conn.Close())
+		default:
+			return errors.Join(errs...)
+		}
+	}
+}
+
+// TokenBucket implements RateLimitStore. The bucket is stored as its token count and the time it was
+// last updated, and expires once it would be full again.
+func (s *RedisStore) TokenBucket(ctx context.Context, key string, rate float64, burst int) (RateLimitResult, error) {
+	var result RateLimitResult
+
+	err := s.withConn(ctx, func(c *redisConn) error {
+		var err error
+		result, err = s.takeToken(c, key, rate, burst)
+		return err
+	})
+
+	return result, err
+}
+
+// takeToken runs a token bucket update on the connection.
+func (s *RedisStore) takeToken(c *redisConn, key string, rate float64, burst int) (result RateLimitResult, err error) {
+	key = s.opts.Prefix + key
+	ttl := int64(math.Ceil(float64(burst)/rate*1000)) + 1000
+
+	for range redisTransactionAttempts {
+		_, err = c.do("WATCH", key)
+		if err != nil {
+			return RateLimitResult{}, err
+		}
+
+		c.send("GET", key)
+		c.send("TIME")
+
+		replies, err := c.receive(2)
+		if err != nil {
+			return RateLimitResult{}, err
+		}
+
+		now, err := parseTime(replies[1])
+		if err != nil {
+			return RateLimitResult{}, err
+		}
+
+		tokens := float64(burst)
+		elapsed := time.Duration(0)
+
+		if value, ok := replies[0].(string); ok {
+			var lastSeen int64
+			tokens, lastSeen, err = parseBucket(value)
+			if err != nil {
+				return RateLimitResult{}, err
+			}
+			elapsed = now.Sub(time.UnixMicro(lastSeen))
+		}
+
+		tokens, result = takeToken(tokens, elapsed, rate, burst)
+
+		if !result.Allowed {
+			_, err = c.do("UNWATCH")
+			return result, err
+		}
+
+		value := strconv.FormatFloat(tokens, 'g', -1, 64) + " " + strconv.FormatInt(now.UnixMicro(), 10)
+
+		c.send("MULTI")
+		c.send("SET", key, value, "PX", strconv.FormatInt(ttl, 10))
+		c.send("EXEC")
+
+		replies, err = c.receive(3)
+		if err != nil {
+			return RateLimitResult{}, err
+		}
+
+		if replies[2] != nil {
+			return result, nil
+		}
+	}
+
+	return RateLimitResult{}, ErrRedisContention
+}
+
+// SlidingWindow implements RateLimitStore. Each fixed window has its own counter, which expires
+// after two windows. The request is counted first and the count is decremented again if it is refused.
+func (s *RedisStore) SlidingWindow(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error) {
+	var result RateLimitResult
+
+	err := s.withConn(ctx, func(c *redisConn) error {
+		var err error
+		result, err = s.countRequest(c, key, limit, window)
+		return err
+	})
+
+	return result, err
+}
+
+// countRequest runs a sliding window update on the connection.
+func (s *RedisStore) countRequest(c *redisConn, key string, limit int, window time.Duration) (result RateLimitResult, err error) {
+	reply, err := c.do("TIME")
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	now, err := parseTime(reply)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	index := now.UnixNano() / int64(window)
+	elapsed := time.Duration(now.UnixNano() - index*int64(window))
+
+	currentKey := s.opts.Prefix + key + ":" + strconv.FormatInt(index, 10)
+	previousKey := s.opts.Prefix + key + ":" + strconv.FormatInt(index-1, 10)
+
+	c.send("INCR", currentKey)
+	c.send("PEXPIRE", currentKey, strconv.FormatInt(2*window.Milliseconds(), 10))
+	c.send("GET", previousKey)
+
+	replies, err := c.receive(3)
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	current, ok := replies[0].(int64)
+	if !ok {
+		return RateLimitResult{}, fmt.Errorf("redis: unexpected reply to INCR: %v", replies[0])
+	}
+
+	var previous int64
+	if value, ok := replies[2].(string); ok {
+		previous, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return RateLimitResult{}, fmt.Errorf("redis: invalid window counter %q", value)
+		}
+	}
+
+	result = slidingWindow(previous, current-1, elapsed, window, limit)
+
+	if !result.Allowed {
+		_, err = c.do("DECR", currentKey)
+	}
+
+	return result, err
+}
+
+// parseTime parses the reply to TIME, which holds the seconds and microseconds of the server's clock.
+func parseTime(reply any) (time.Time, error) {
+	parts, ok := reply.([]any)
+	if !ok || len(parts) != 2 {
+		return time.Time{}, fmt.Errorf("redis: unexpected reply to TIME: %v", reply)
+	}
+
+	seconds, err := strconv.ParseInt(fmt.Sprint(parts[0]), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("redis: unexpected reply to TIME: %v", reply)
+	}
+
+	microseconds, err := strconv.ParseInt(fmt.Sprint(parts[1]), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("redis: unexpected reply to TIME: %v", reply)
+	}
+
+	return time.Unix(seconds, microseconds*int64(time.Microsecond)), nil
+}
+
+// parseBucket parses a stored token bucket.
+func parseBucket(value string) (float64, int64, error) {
+	tokensValue, lastSeenValue, _ := strings.Cut(value, " ")
+
+	tokens, err := strconv.ParseFloat(tokensValue, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("redis: invalid token bucket %q", value)
+	}
+
+	lastSeen, err := strconv.ParseInt(lastSeenValue, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("redis: invalid token bucket %q", value)
+	}
+
+	return tokens, lastSeen, nil
+}
+
+// withConn runs fn on a connection and releases it. If an idle connection turns out to have been
+// closed, for example by the server's idle timeout, fn is run once more on a new connection.
+func (s *RedisStore) withConn(ctx context.Context, fn func(c *redisConn) error) error {
+	deadline := time.Now().Add(s.opts.Timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+
+	c, idle, err := s.get(ctx, deadline, true)
+	if err != nil {
+		return err
+	}
+
+	err = fn(c)
+
+	if err != nil && idle && isClosedConnError(err) {
+		c.conn.Close()
+
+		c, _, err = s.get(ctx, deadline, false)
+		if err != nil {
+			return err
+		}
+
+		err = fn(c)
+	}
+
+	s.put(c, err)
+
+	return err
+}
+
+// isClosedConnError reports whether the error shows that the connection was closed by the other end.
+func isClosedConnError(err error) bool {
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.EPIPE)
+}
+
+// get returns an idle connection, when allowed and available, or opens a new one,
+// with its deadline set for the operation. It reports whether the connection was idle.
+func (s *RedisStore) get(ctx context.Context, deadline time.Time, allowIdle bool) (*redisConn, bool, error) {
+	var c *redisConn
+	var idle bool
+
+	if allowIdle {
+		select {
+		case c = <-s.idle:
+			idle = true
+		default:
+		}
+	}
+
+	if c == nil {
+		var err error
+		c, err = s.dial(ctx, deadline)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	err := c.conn.SetDeadline(deadline)
+	if err != nil {
+		c.conn.Close()
+		return nil, false, err
+	}
+
+	return c, idle, nil
+}
+
+// put returns the connection to the idle pool. Connections are closed instead after an error,
+// since a reply may still be unread, or when the pool is full or the store closed.
+func (s *RedisStore) put(c *redisConn, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err != nil || s.closed {
+		c.conn.Close()
+		return
+	}
+
+	select {
+	case s.idle <- c:
+	default:
+		c.conn.Close()
+	}
+}
+
+// dial opens a connection, authenticating and selecting the database as configured.
+func (s *RedisStore) dial(ctx context.Context, deadline time.Time) (*redisConn, error) {
+	dialer := net.Dialer{Deadline: deadline}
+
+	conn, err := dialer.DialContext(ctx, "tcp", s.opts.Addr)
+	if err != nil {
+		return nil, err
+	}
+
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	c := &redisConn{conn: conn, rd: bufio.NewReader(conn), wr: bufio.NewWriter(conn)}
+
+	if s.opts.Password != "" {
+		if s.opts.Username != "" {
+			_, err = c.do("AUTH", s.opts.Username, s.opts.Password)
+		} else {
+			_, err = c.do("AUTH", s.opts.Password)
+		}
+
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	if s.opts.DB != 0 {
+		_, err = c.do("SELECT", strconv.Itoa(s.opts.DB))
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return c, nil
+}
+
+// redisConn is a connection speaking the Redis serialization protocol (RESP2).
+type redisConn struct {
+	conn net.Conn
+	rd   *bufio.Reader
+	wr   *bufio.Writer
+}
+
+// send buffers a command; it is written by the next call to receive.
+func (c *redisConn) send(args ...string) {
+	c.wr.WriteString("*" + strconv.Itoa(len(args)) + "\r\n")
+
+	for _, arg := range args {
+		c.wr.WriteString("$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n")
+	}
+}
+
+// receive writes the buffered commands and reads n replies.
+// It returns the first error reply, after reading every reply.
+func (c *redisConn) receive(n int) ([]any, error) {
+	err := c.wr.Flush()
+	if err != nil {
+		return nil, err
+	}
+
+	replies := make([]any, n)
+	var replyErr error
+
+	for i := range replies {
+		replies[i], err = c.readReply()
+		if err != nil {
+			var redisError RedisError
+			if !errors.As(err, &redisError) {
+				return nil, err
+			}
+
+			if replyErr == nil {
+				replyErr = err
+			}
+		}
+	}
+
+	return replies, replyErr
+}
+
+// do sends a single command and reads its reply.
+func (c *redisConn) do(args ...string) (any, error) {
+	c.send(args...)
+
+	replies, err := c.receive(1)
+	if err != nil {
+		return nil, err
+	}
+
+	return replies[0], nil
+}
+
+// readReply reads a reply as a string, int64, []any or nil value. Error replies are returned as a RedisError.
+func (c *redisConn) readReply() (any, error) {
+	line, err := c.rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	line, ok := strings.CutSuffix(line, "\r\n")
+	if !ok || line == "" {
+		return nil, fmt.Errorf("redis: invalid reply %q", line)
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+
+	case '-':
+		return nil, RedisError(line[1:])
+
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+
+	case '$':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		if size < 0 {
+			return nil, nil
+		}
+
+		buf := make([]byte, size+2)
+
+		_, err = io.ReadFull(c.rd, buf)
+		if err != nil {
+			return nil, err
+		}
+
+		return string(buf[:size]), nil
+
+	case '*':
+		size, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		if size < 0 {
+			return nil, nil
+		}
+
+		items := make([]any, size)
+
+		for i := range items {
+			items[i], err = c.readReply()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return items, nil
+	}
+
+	return nil, fmt.Errorf("redis: invalid reply %q", line)
+}
@@ -0,0 +1,513 @@
+package middleware
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/windevkay/flhoutils/assert"
+)
+
+// fakeRedis is a local stand-in for a Redis server that implements the commands used by RedisStore.
+type fakeRedis struct {
+	listener net.Listener
+
+	mu       sync.Mutex
+	password string
+	now      time.Time
+	data     map[string]string
+	versions map[string]int
+	commands []string
+	conns    []net.Conn
+
+	// beforeExec is called, without the lock held, before each EXEC is run.
+	beforeExec func(r *fakeRedis)
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+
+	r := &fakeRedis{
+		listener: listener,
+		data:     make(map[string]string),
+		versions: make(map[string]int),
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go r.serve(conn)
+		}
+	}()
+
+	t.Cleanup(func() { listener.Close() })
+
+	return r
+}
+
+func (r *fakeRedis) addr() string {
+	return r.listener.Addr().String()
+}
+
+func (r *fakeRedis) configure(password string, beforeExec func(r *fakeRedis)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.password = password
+	r.beforeExec = beforeExec
+}
+
+// closeConnections closes every open connection, as the server's idle timeout would.
+func (r *fakeRedis) closeConnections() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, conn := range r.conns {
+		conn.Close()
+	}
+}
+
+// setTime sets the clock reported by TIME, which otherwise reports the current time.
+func (r *fakeRedis) setTime(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.now = now
+}
+
+func (r *fakeRedis) set(key, value string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.data[key] = value
+	r.versions[key]++
+}
+
+func (r *fakeRedis) get(key string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.data[key]
+}
+
+func (r *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+
+	rd := bufio.NewReader(conn)
+
+	r.mu.Lock()
+	r.conns = append(r.conns, conn)
+	password := r.password
+	beforeExec := r.beforeExec
+	r.mu.Unlock()
+
+	authenticated := password == ""
+	watched := map[string]int{}
+	var queued [][]string
+	inMulti := false
+
+	for {
+		args, err := readCommand(rd)
+		if err != nil {
+			return
+		}
+
+		name := strings.ToUpper(args[0])
+
+		r.mu.Lock()
+		r.commands = append(r.commands, strings.Join(args, " "))
+		r.mu.Unlock()
+
+		var reply string
+
+		switch {
+		case name == "AUTH":
+			if args[len(args)-1] != password {
+				reply = "-WRONGPASS invalid username-password pair\r\n"
+				break
+			}
+			authenticated = true
+			reply = "+OK\r\n"
+
+		case !authenticated:
+			reply = "-NOAUTH Authentication required.\r\n"
+
+		case name == "MULTI":
+			inMulti = true
+			reply = "+OK\r\n"
+
+		case name == "EXEC":
+			r.mu.Lock()
+			beforeExec = r.beforeExec
+			r.mu.Unlock()
+
+			if beforeExec != nil {
+				beforeExec(r)
+			}
+
+			r.mu.Lock()
+			conflict := false
+			for key, version := range watched {
+				if r.versions[key] != version {
+					conflict = true
+				}
+			}
+
+			if conflict {
+				reply = "*-1\r\n"
+			} else {
+				reply = "*" + strconv.Itoa(len(queued)) + "\r\n"
+				for _, command := range queued {
+					reply += r.run(command)
+				}
+			}
+			r.mu.Unlock()
+
+			watched = map[string]int{}
+			queued = nil
+			inMulti = false
+
+		case inMulti:
+			queued = append(queued, args)
+			reply = "+QUEUED\r\n"
+
+		case name == "WATCH":
+			r.mu.Lock()
+			for _, key := range args[1:] {
+				watched[key] = r.versions[key]
+			}
+			r.mu.Unlock()
+			reply = "+OK\r\n"
+
+		case name == "UNWATCH":
+			watched = map[string]int{}
+			reply = "+OK\r\n"
+
+		default:
+			r.mu.Lock()
+			reply = r.run(args)
+			r.mu.Unlock()
+		}
+
+		_, err = io.WriteString(conn, reply)
+		if err != nil {
+			return
+		}
+	}
+}
+
+// run executes a data command with the lock held and returns the encoded reply.
+func (r *fakeRedis) run(args []string) string {
+	switch strings.ToUpper(args[0]) {
+	case "SELECT", "PEXPIRE":
+		return ":1\r\n"
+
+	case "TIME":
+		now := r.now
+		if now.IsZero() {
+			now = time.Now()
+		}
+		seconds := strconv.FormatInt(now.Unix(), 10)
+		microseconds := strconv.Itoa(now.Nanosecond() / 1000)
+		return "*2\r\n$" + strconv.Itoa(len(seconds)) + "\r\n" + seconds + "\r\n$" + strconv.Itoa(len(microseconds)) + "\r\n" + microseconds + "\r\n"
+
+	case "GET":
+		value, ok := r.data[args[1]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+
+	case "SET":
+		r.data[args[1]] = args[2]
+		r.versions[args[1]]++
+		return "+OK\r\n"
+
+	case "INCR", "DECR":
+		n, _ := strconv.ParseInt(r.data[args[1]], 10, 64)
+		if strings.ToUpper(args[0]) == "INCR" {
+			n++
+		} else {
+			n--
+		}
+		r.data[args[1]] = strconv.FormatInt(n, 10)
+		r.versions[args[1]]++
+		return ":" + strconv.FormatInt(n, 10) + "\r\n"
+	}
+
+	return "-ERR unknown command '" + args[0] + "'\r\n"
+}
+
+func readCommand(rd *bufio.Reader) ([]string, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+
+	args := make([]string, n)
+
+	for i := range args {
+		line, err = rd.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+
+		buf := make([]byte, size+2)
+
+		_, err = io.ReadFull(rd, buf)
+		if err != nil {
+			return nil, err
+		}
+
+		args[i] = string(buf[:size])
+	}
+
+	return args, nil
+}
+
+func TestRedisStoreTokenBucket(t *testing.T) {
+	server := newFakeRedis(t)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store := NewRedisStore(RedisOptions{Addr: server.addr()})
+	defer store.Close()
+
+	tests := []struct {
+		name       string
+		elapsed    time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+		stored     string
+	}{
+		{name: "Full bucket", allowed: true, remaining: 1, stored: "1 1704067200000000"},
+		{name: "Last token", allowed: true, remaining: 0, stored: "0 1704067200000000"},
+		{name: "Empty bucket", elapsed: 250 * time.Millisecond, allowed: false, remaining: 0, retryAfter: 250 * time.Millisecond, stored: "0 1704067200000000"},
+		{name: "Refilled token", elapsed: 250 * time.Millisecond, allowed: true, remaining: 0, stored: "0 1704067200500000"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			now = now.Add(tc.elapsed)
+			server.setTime(now)
+
+			result, err := store.TokenBucket(context.Background(), "client", 2, 2)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			assert.Equal(t, result.Allowed, tc.allowed)
+			assert.Equal(t, result.Limit, 2)
+			assert.Equal(t, result.Remaining, tc.remaining)
+			assert.Equal(t, result.RetryAfter, tc.retryAfter)
+			assert.Equal(t, server.get("ratelimit:client"), tc.stored)
+		})
+	}
+}
+
+func TestRedisStoreTokenBucketConflict(t *testing.T) {
+	server := newFakeRedis(t)
+
+	conflicts := 2
+	server.configure("", func(r *fakeRedis) {
+		if conflicts > 0 {
+			conflicts--
+			r.set("ratelimit:client", "2 1704067200000000")
+		}
+	})
+
+	server.setTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	store := NewRedisStore(RedisOptions{Addr: server.addr()})
+	defer store.Close()
+
+	result, err := store.TokenBucket(context.Background(), "client", 1, 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	assert.Equal(t, result.Allowed, true)
+	assert.Equal(t, result.Remaining, 1)
+	assert.Equal(t, conflicts, 0)
+	assert.Equal(t, server.get("ratelimit:client"), "1 1704067200000000")
+
+	server.configure("", func(r *fakeRedis) {
+		r.set("ratelimit:client", "3 1704067200000000")
+	})
+
+	_, err = store.TokenBucket(context.Background(), "client", 1, 3)
+	assert.Equal(t, err, ErrRedisContention)
+}
+
+func TestRedisStoreTokenBucketClockSkew(t *testing.T) {
+	server := newFakeRedis(t)
+	server.setTime(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	// The bucket was last written one second "in the future", as seen from the current clock.
+	server.set("ratelimit:client", "1 1704067201000000")
+
+	store := NewRedisStore(RedisOptions{Addr: server.addr()})
+	defer store.Close()
+
+	result, err := store.TokenBucket(context.Background(), "client", 2, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	assert.Equal(t, result.Allowed, true)
+	assert.Equal(t, result.Remaining, 0)
+}
+
+func TestRedisStoreSlidingWindow(t *testing.T) {
+	server := newFakeRedis(t)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	store := NewRedisStore(RedisOptions{Addr: server.addr(), Prefix: "limits:"})
+	defer store.Close()
+
+	window := 10 * time.Second
+	index := strconv.FormatInt(now.UnixNano()/int64(window), 10)
+	server.set("limits:client:"+strconv.FormatInt(now.UnixNano()/int64(window)-1, 10), "2")
+
+	tests := []struct {
+		name      string
+		elapsed   time.Duration
+		allowed   bool
+		remaining int
+		counter   string
+	}{
+		{name: "Previous window is weighted", allowed: true, remaining: 0, counter: "1"},
+		{name: "Limit reached", elapsed: 2 * time.Second, allowed: false, remaining: 0, counter: "1"},
+		{name: "Previous window has slid past", elapsed: 4 * time.Second, allowed: true, remaining: 0, counter: "2"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			now = now.Add(tc.elapsed)
+			server.setTime(now)
+
+			result, err := store.SlidingWindow(context.Background(), "client", 3, window)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			assert.Equal(t, result.Allowed, tc.allowed)
+			assert.Equal(t, result.Remaining, tc.remaining)
+			assert.Equal(t, server.get("limits:client:"+index), tc.counter)
+		})
+	}
+}
+
+func TestRedisStoreConnection(t *testing.T) {
+	server := newFakeRedis(t)
+	server.configure("secret", nil)
+
+	store := NewRedisStore(RedisOptions{Addr: server.addr(), Username: "app", Password: "secret", DB: 2})
+	defer store.Close()
+
+	for range 2 {
+		_, err := store.TokenBucket(context.Background(), "client", 1, 1)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	server.mu.Lock()
+	commands := strings.Join(server.commands[:2], ", ")
+	connections := strings.Count(strings.Join(server.commands, "\n"), "AUTH")
+	server.mu.Unlock()
+
+	assert.Equal(t, commands, "AUTH app secret, SELECT 2")
+	assert.Equal(t, connections, 1)
+}
+
+func TestRedisStoreClosedIdleConnection(t *testing.T) {
+	server := newFakeRedis(t)
+
+	store := NewRedisStore(RedisOptions{Addr: server.addr()})
+	defer store.Close()
+
+	_, err := store.SlidingWindow(context.Background(), "client", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	server.closeConnections()
+
+	result, err := store.SlidingWindow(context.Background(), "client", 10, time.Minute)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	server.mu.Lock()
+	connections := len(server.conns)
+	server.mu.Unlock()
+
+	assert.Equal(t, result.Allowed, true)
+	assert.Equal(t, result.Remaining, 8)
+	assert.Equal(t, connections, 2)
+}
+
+func TestRedisStoreErrorReply(t *testing.T) {
+	server := newFakeRedis(t)
+	server.configure("secret", nil)
+
+	store := NewRedisStore(RedisOptions{Addr: server.addr(), Password: "wrong"})
+	defer store.Close()
+
+	_, err := store.SlidingWindow(context.Background(), "client", 1, time.Minute)
+
+	var redisError RedisError
+	assert.Equal(t, errors.As(err, &redisError), true)
+	assert.Equal(t, err.Error(), "redis: WRONGPASS invalid username-password pair")
+}
+
+func TestRateLimitRedisStore(t *testing.T) {
+	server := newFakeRedis(t)
+
+	store := NewRedisStore(RedisOptions{Addr: server.addr()})
+	defer store.Close()
+
+	replica := func() http.Handler {
+		return RateLimit(RateLimitOptions{Algorithm: SlidingWindow, Limit: 2, Window: time.Hour, Store: store})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}))
+	}
+
+	replicas := []http.Handler{replica(), replica(), replica()}
+	statuses := []int{http.StatusNoContent, http.StatusNoContent, http.StatusTooManyRequests}
+
+	for i, handler := range replicas {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, w.Code, statuses[i])
+	}
+}
@@ -0,0 +1,209 @@
+package validator
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidateStruct checks every exported field of dst against the rules in its `validate` tag
+// and records a message for each failure in the validator, keyed by the field's JSON name.
+// Rules are separated by commas and checked in order, for example:
+//
+//	Title  string   `json:"title" validate:"required,max=500"`
+//	Email  string   `json:"email" validate:"required,email"`
+//	Genres []string `json:"genres" validate:"required,min=1,max=5,unique"`
+//	Status string   `json:"status" validate:"oneof=draft published"`
+//
+// The supported rules are required, min, max, email, oneof and unique. Fields left at their zero value,
+// including nil pointers, are only checked by required, so the other rules apply to optional fields
+// only when they are provided. A pointer to a zero value counts as provided.
+// Rules on strings and collections apply to their length, and on numbers to their value.
+// Nested structs, and slices of structs, are validated too, with errors keyed by their path
+// such as "address.city" or "items[2].quantity".
+// ValidateStruct panics if dst is not a struct or a pointer to one, or if a tag is malformed.
+func ValidateStruct(v *Validator, dst any) {
+	rv := reflect.Indirect(reflect.ValueOf(dst))
+
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validator: ValidateStruct requires a struct, got %T", dst))
+	}
+
+	validateFields(v, rv)
+}
+
+// validateFields applies the tag rules of each exported field of the struct value.
+func validateFields(v *Validator, rv reflect.Value) {
+	rt := rv.Type()
+
+	for i := range rt.NumField() {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		value := rv.Field(i)
+
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			validateFields(v, value)
+			continue
+		}
+
+		tag := field.Tag.Get("validate")
+		if tag == "-" {
+			continue
+		}
+
+		key := fieldKey(field)
+		target := reflect.Indirect(value)
+
+		if tag != "" {
+			rules := strings.Split(tag, ",")
+			required := slices.ContainsFunc(rules, func(rule string) bool { return strings.TrimSpace(rule) == "required" })
+
+			for _, rule := range rules {
+				name, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+				if name == "required" {
+					v.Check(!value.IsZero(), key, "must be provided")
+					continue
+				}
+
+				if !target.IsValid() || !required && value.IsZero() {
+					continue
+				}
+
+				ok, message := checkRule(target, name, param)
+				v.Check(ok, key, message)
+			}
+		}
+
+		validateNested(v, key, target)
+	}
+}
+
+// validateNested validates nested structs, and structs held in slices and arrays, within the scope of the field.
+func validateNested(v *Validator, key string, value reflect.Value) {
+	if !value.IsValid() {
+		return
+	}
+
+	switch value.Kind() {
+	case reflect.Struct:
+		validateFields(v.Scope(key), value)
+
+	case reflect.Slice, reflect.Array:
+		elem := value.Type().Elem()
+		if elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+
+		if elem.Kind() != reflect.Struct {
+			return
+		}
+
+		for i := range value.Len() {
+			item := reflect.Indirect(value.Index(i))
+			if item.IsValid() {
+				validateFields(v.Index(key, i), item)
+			}
+		}
+	}
+}
+
+// fieldKey returns the name a field is reported under, preferring its JSON name.
+func fieldKey(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+
+	if name == "" || name == "-" {
+		return field.Name
+	}
+
+	return name
+}
+
+// checkRule reports whether the value satisfies a single rule, along with the message to record if it does not.
+func checkRule(value reflect.Value, name, param string) (bool, string) {
+	switch name {
+	case "min":
+		limit := parseLimit(name, param)
+		return compareLength(value, limit, param, true)
+
+	case "max":
+		limit := parseLimit(name, param)
+		return compareLength(value, limit, param, false)
+
+	case "email":
+		if value.Kind() != reflect.String {
+			panic(fmt.Sprintf("validator: email rule requires a string, got %s", value.Type()))
+		}
+		return Matches(value.String(), EmailRX), "must be a valid email address"
+
+	case "oneof":
+		permitted := strings.Fields(param)
+		return PermittedValue(fmt.Sprint(value.Interface()), permitted...), "must be one of " + strings.Join(permitted, ", ")
+
+	case "unique":
+		if value.Kind() != reflect.Slice && value.Kind() != reflect.Array || !value.Type().Elem().Comparable() {
+			panic(fmt.Sprintf("validator: unique rule requires a slice of comparable values, got %s", value.Type()))
+		}
+
+		values := make([]any, value.Len())
+		for i := range values {
+			values[i] = value.Index(i).Interface()
+		}
+		return Unique(values), "must not contain duplicate values"
+
+	default:
+		panic(fmt.Sprintf("validator: unknown rule %q", name))
+	}
+}
+
+// parseLimit parses the numeric parameter of the min and max rules.
+func parseLimit(name, param string) float64 {
+	limit, err := strconv.ParseFloat(param, 64)
+	if err != nil {
+		panic(fmt.Sprintf("validator: %s rule requires a number, got %q", name, param))
+	}
+
+	return limit
+}
+
+// compareLength checks the length of strings and collections, or the value of numbers, against the limit.
+func compareLength(value reflect.Value, limit float64, param string, atLeast bool) (bool, string) {
+	var size float64
+	var minMessage, maxMessage string
+
+	switch value.Kind() {
+	case reflect.String:
+		size = float64(utf8.RuneCountInString(value.String()))
+		minMessage = "must be at least " + param + " characters long"
+		maxMessage = "must not be more than " + param + " characters long"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		size = float64(value.Len())
+		minMessage = "must contain at least " + param + " items"
+		maxMessage = "must not contain more than " + param + " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = float64(value.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		size = float64(value.Uint())
+	case reflect.Float32, reflect.Float64:
+		size = value.Float()
+	default:
+		panic(fmt.Sprintf("validator: min and max rules do not support %s", value.Type()))
+	}
+
+	if minMessage == "" {
+		minMessage = "must be greater than or equal to " + param
+		maxMessage = "must be less than or equal to " + param
+	}
+
+	if atLeast {
+		return size >= limit, minMessage
+	}
+
+	return size <= limit, maxMessage
+}
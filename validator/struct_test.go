@@ -0,0 +1,104 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/windevkay/flhoutils/assert"
+)
+
+type movieInput struct {
+	Title   string   `json:"title" validate:"required,max=10"`
+	Year    int32    `json:"year" validate:"required,min=1888,max=2100"`
+	Genres  []string `json:"genres" validate:"required,min=1,max=3,unique"`
+	Email   string   `json:"email" validate:"email"`
+	Status  string   `json:"status" validate:"oneof=draft published"`
+	Runtime *int     `json:"runtime" validate:"min=1"`
+	Notes   string
+}
+
+func TestValidateStruct(t *testing.T) {
+	zero := 0
+
+	tests := []struct {
+		name  string
+		input movieInput
+		want  map[string]string
+	}{
+		{
+			name:  "Valid input has no errors",
+			input: movieInput{Title: "Up", Year: 2009, Genres: []string{"animation"}, Email: "a@b.com", Status: "draft"},
+			want:  map[string]string{},
+		},
+		{
+			name:  "Missing values are required",
+			input: movieInput{},
+			want:  map[string]string{"title": "must be provided", "year": "must be provided", "genres": "must be provided"},
+		},
+		{
+			name:  "Optional values can be left empty",
+			input: movieInput{Title: "Up", Year: 2009, Genres: []string{"animation"}},
+			want:  map[string]string{},
+		},
+		{
+			name: "Rules are checked against their fields",
+			input: movieInput{
+				Title:   "A very long title",
+				Year:    1700,
+				Genres:  []string{"drama", "drama"},
+				Email:   "not-an-email",
+				Status:  "deleted",
+				Runtime: &zero,
+			},
+			want: map[string]string{
+				"title":   "must not be more than 10 characters long",
+				"year":    "must be greater than or equal to 1888",
+				"genres":  "must not contain duplicate values",
+				"email":   "must be a valid email address",
+				"status":  "must be one of draft, published",
+				"runtime": "must be greater than or equal to 1",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			v := New()
+			ValidateStruct(v, &tc.input)
+
+			assert.Equal(t, len(v.Errors), len(tc.want))
+			for key, message := range tc.want {
+				assert.Equal(t, v.Errors[key], message)
+			}
+		})
+	}
+}
+
+func TestValidateStructPanicsOnBadInput(t *testing.T) {
+	defer func() {
+		assert.Equal(t, recover() != nil, true)
+	}()
+
+	ValidateStruct(New(), "not a struct")
+}
+
+type orderItem struct {
+	Quantity int `json:"quantity" validate:"min=1"`
+}
+
+type orderInput struct {
+	Address struct {
+		City string `json:"city" validate:"required"`
+	} `json:"address"`
+	Items []*orderItem `json:"items" validate:"required"`
+}
+
+func TestValidateStructNested(t *testing.T) {
+	input := orderInput{Items: []*orderItem{{Quantity: 1}, nil, {Quantity: -1}, {}}}
+
+	v := New()
+	ValidateStruct(v, input)
+
+	assert.Equal(t, len(v.Errors), 2)
+	assert.Equal(t, v.Errors["address.city"], "must be provided")
+	assert.Equal(t, v.Errors["items[2].quantity"], "must be greater than or equal to 1")
+}
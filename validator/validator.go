@@ -1,34 +1,227 @@
 package validator
 
 import (
+	"bytes"
+	"encoding/json"
 	"regexp"
 	"slices"
+	"strconv"
+	"strings"
 )
 
 var EmailRX = regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
 
+// Validator records validation errors keyed by field name.
+// Errors holds the first message recorded for each field. Validators created with NewMulti
+// additionally keep every message, which are available in order through FieldErrors.
+// Scope and Index return validators for nested fields that record into the same errors.
 type Validator struct {
 	Errors map[string]string
+
+	prefix string
+	record *record
+}
+
+// record tracks the order and every message of the recorded errors.
+// It is shared by a validator and all of its scoped validators.
+type record struct {
+	multi    bool
+	fields   []string
+	messages map[string][]string
+}
+
+// FieldError holds the messages recorded for a single field.
+type FieldError struct {
+	Field    string
+	Messages []string
+}
+
+// FieldErrors is a list of field errors in the order the fields first failed validation.
+// It is encoded as a JSON object mapping each field to its messages, preserving that order.
+type FieldErrors []FieldError
+
+// Validatable is implemented by types that check their own fields.
+// It is used by helpers.DecodeJSON to validate decoded request bodies.
+type Validatable interface {
+	Validate(v *Validator)
 }
 
 // New creates a new instance of the Validator struct.
 func New() *Validator {
-	return &Validator{Errors: make(map[string]string)}
+	return &Validator{
+		Errors: make(map[string]string),
+		record: &record{messages: make(map[string][]string)},
+	}
+}
+
+// NewMulti creates a Validator that keeps every message recorded for a field, rather than only the first.
+func NewMulti() *Validator {
+	v := New()
+	v.record.multi = true
+
+	return v
+}
+
+// init prepares a validator that was not created with New, so it can record errors and be scoped.
+func (v *Validator) init() {
+	if v.Errors == nil {
+		v.Errors = make(map[string]string)
+	}
+
+	if v.record == nil {
+		v.record = &record{messages: make(map[string][]string)}
+	}
+}
+
+// Scope returns a validator that records errors under the given field of this validator.
+// For example, an error added with key "city" to v.Scope("address") is recorded as "address.city".
+func (v *Validator) Scope(field string) *Validator {
+	v.init()
+
+	return &Validator{Errors: v.Errors, prefix: v.key(field), record: v.record}
+}
+
+// Index returns a validator that records errors under an element of the given list field.
+// For example, an error added with key "quantity" to v.Index("items", 2) is recorded as "items[2].quantity".
+func (v *Validator) Index(field string, i int) *Validator {
+	v.init()
+
+	return &Validator{Errors: v.Errors, prefix: v.key(field) + "[" + strconv.Itoa(i) + "]", record: v.record}
+}
+
+// key returns the full path of a field within the scope of the validator.
+func (v *Validator) key(field string) string {
+	switch {
+	case v.prefix == "":
+		return field
+	case field == "":
+		return v.prefix
+	default:
+		return v.prefix + "." + field
+	}
+}
+
+// JSONPointer converts a field path such as "items[2].quantity" into an RFC 6901 JSON pointer
+// such as "/items/2/quantity", for clients that locate errors by pointer.
+func JSONPointer(key string) string {
+	escaper := strings.NewReplacer("~", "~0", "/", "~1")
+
+	var b strings.Builder
+
+	for _, part := range strings.Split(key, ".") {
+		name, rest, _ := strings.Cut(part, "[")
+
+		if name != "" {
+			b.WriteString("/" + escaper.Replace(name))
+		}
+
+		for rest != "" {
+			var index string
+			index, rest, _ = strings.Cut(rest, "]")
+			rest = strings.TrimPrefix(rest, "[")
+			b.WriteString("/" + index)
+		}
+	}
+
+	return b.String()
 }
 
 // Valid checks if the Validator instance has any errors.
 // It returns true if there are no errors, otherwise false.
+// For a scoped validator only the errors recorded within its scope are considered.
 func (v *Validator) Valid() bool {
-	return len(v.Errors) == 0
+	if v.prefix == "" {
+		return len(v.Errors) == 0
+	}
+
+	for key := range v.Errors {
+		if key == v.prefix || strings.HasPrefix(key, v.prefix+".") || strings.HasPrefix(key, v.prefix+"[") {
+			return false
+		}
+	}
+
+	return true
 }
 
 // AddError adds an error message to the Validator's Errors map.
 // If the given key does not exist in the Errors map, it adds the key-value pair to the map.
 // The key is used to identify the error, and the message provides a description of the error.
+// Validators created with NewMulti also keep the messages of keys that already exist.
 func (v *Validator) AddError(key, message string) {
+	v.init()
+
+	key = v.key(key)
+
 	if _, exists := v.Errors[key]; !exists {
 		v.Errors[key] = message
+		v.record.fields = append(v.record.fields, key)
+		v.record.messages[key] = []string{message}
+		return
+	}
+
+	if v.record.multi {
+		v.record.messages[key] = append(v.record.messages[key], message)
+	}
+}
+
+// FieldErrors returns the recorded errors in the order the fields first failed validation.
+// Entries placed in Errors directly, rather than through AddError, follow in key order.
+// Scoped validators return the errors of every scope.
+func (v *Validator) FieldErrors() FieldErrors {
+	v.init()
+
+	errs := make(FieldErrors, 0, len(v.Errors))
+
+	for _, field := range v.record.fields {
+		if _, exists := v.Errors[field]; exists {
+			errs = append(errs, FieldError{Field: field, Messages: v.record.messages[field]})
+		}
 	}
+
+	var remaining []string
+	for field := range v.Errors {
+		if _, recorded := v.record.messages[field]; !recorded {
+			remaining = append(remaining, field)
+		}
+	}
+
+	slices.Sort(remaining)
+	for _, field := range remaining {
+		errs = append(errs, FieldError{Field: field, Messages: []string{v.Errors[field]}})
+	}
+
+	return errs
+}
+
+// MarshalJSON encodes the field errors as a JSON object whose keys keep their order.
+func (fe FieldErrors) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteByte('{')
+
+	for i, fieldError := range fe {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(fieldError.Field)
+		if err != nil {
+			return nil, err
+		}
+
+		messages, err := json.Marshal(fieldError.Messages)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(messages)
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
 }
 
 // Check checks if the given condition is false and adds an error to the validator if it is.
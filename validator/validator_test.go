@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/windevkay/flhoutils/assert"
@@ -65,3 +66,79 @@ func TestMatches(t *testing.T) {
 		})
 	}
 }
+
+func TestAddError(t *testing.T) {
+	tests := []struct {
+		name string
+		v    *Validator
+		want string
+	}{
+		{name: "Keeps the first message per field", v: New(), want: `{"title":["must be provided"],"year":["must be provided"]}`},
+		{name: "Keeps every message per field", v: NewMulti(), want: `{"title":["must be provided","must not be more than 500 bytes long"],"year":["must be provided"]}`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.v.AddError("title", "must be provided")
+			tc.v.AddError("year", "must be provided")
+			tc.v.AddError("title", "must not be more than 500 bytes long")
+
+			assert.Equal(t, tc.v.Errors["title"], "must be provided")
+
+			js, err := json.Marshal(tc.v.FieldErrors())
+			if err != nil {
+				t.Fatalf("Failed to marshal field errors: %v", err)
+			}
+			assert.Equal(t, string(js), tc.want)
+		})
+	}
+}
+
+func TestFieldErrorsOrder(t *testing.T) {
+	v := New()
+	v.AddError("zeta", "first")
+	v.AddError("alpha", "second")
+	v.Errors["beta"] = "set directly"
+
+	errs := v.FieldErrors()
+
+	assert.Equal(t, len(errs), 3)
+	assert.Equal(t, errs[0].Field, "zeta")
+	assert.Equal(t, errs[1].Field, "alpha")
+	assert.Equal(t, errs[2].Field, "beta")
+}
+
+func TestScopedValidators(t *testing.T) {
+	v := New()
+	address := v.Scope("address")
+	address.Check(false, "city", "must be provided")
+	v.Index("items", 2).AddError("quantity", "must be greater than zero")
+	v.Scope("contact").Scope("phone").AddError("", "must be provided")
+
+	assert.Equal(t, v.Errors["address.city"], "must be provided")
+	assert.Equal(t, v.Errors["items[2].quantity"], "must be greater than zero")
+	assert.Equal(t, v.Errors["contact.phone"], "must be provided")
+	assert.Equal(t, v.Valid(), false)
+	assert.Equal(t, address.Valid(), false)
+	assert.Equal(t, v.Scope("addresses").Valid(), true)
+}
+
+func TestJSONPointer(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{name: "Top level field", key: "title", want: "/title"},
+		{name: "Nested field", key: "address.city", want: "/address/city"},
+		{name: "Indexed field", key: "items[2].quantity", want: "/items/2/quantity"},
+		{name: "Nested index", key: "matrix[1][0]", want: "/matrix/1/0"},
+		{name: "Escaped field", key: "a/b~c", want: "/a~1b~0c"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, JSONPointer(tc.key), tc.want)
+		})
+	}
+}